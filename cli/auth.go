@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/lunjon/http/cli/options"
+	"github.com/lunjon/http/internal/client"
+	"github.com/lunjon/http/internal/config"
+	"github.com/lunjon/http/internal/oauth2"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	authTypeBasic  = "basic"
+	authTypeDigest = "digest"
+)
+
+var aliasPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// passwordPrompt asks the user for the password of username.
+type passwordPrompt func(username string) (string, error)
+
+// Returns the signer given by the authentication flags.
+// If none is given, an OAuth2 configuration matching an alias
+// in the URL is used, unless the Authorization header is set.
+func buildSigner(
+	cmd *cobra.Command,
+	cfg cliConfig,
+	appConfig config.Config,
+	settings client.Settings,
+	header http.Header,
+	url string,
+	logger *log.Logger,
+) (client.RequestSigner, error) {
+	flags := cmd.Flags()
+	prompt := terminalPasswordPrompt(cfg.errors)
+
+	signRequest, _ := flags.GetBool(options.AWSSigV4FlagName)
+	if signRequest {
+		return buildAWSigner(cmd, appConfig.AWS, logger)
+	}
+	if flags.Changed(options.AWSPresignFlagName) {
+		return nil, fmt.Errorf("%s option requires %s", options.AWSPresignFlagName, options.AWSSigV4FlagName)
+	}
+
+	if auth, _ := flags.GetString(options.AuthFlagName); auth != "" {
+		username, password, err := parseCredentials(auth, prompt)
+		if err != nil {
+			return nil, err
+		}
+
+		authType, _ := flags.GetString(options.AuthTypeFlagName)
+		switch strings.ToLower(authType) {
+		case authTypeBasic:
+			logger.Print("Using basic authentication")
+			return client.NewBasicSigner(username, password), nil
+		case authTypeDigest:
+			logger.Print("Using digest authentication")
+			return client.NewDigestSigner(username, password), nil
+		default:
+			return nil, fmt.Errorf("invalid authentication type: %s", authType)
+		}
+	}
+
+	if key, _ := flags.GetString(options.APIKeyFlagName); key != "" {
+		name, _ := flags.GetString(options.APIKeyNameFlagName)
+		in, _ := flags.GetString(options.APIKeyInFlagName)
+		logger.Printf("Using API key in %s %s", in, name)
+		return client.NewAPIKeySigner(key, name, client.APIKeyLocation(strings.ToLower(in)))
+	}
+
+	if name, _ := flags.GetString(options.SignFlagName); name != "" {
+		logger.Printf("Using signer: %s", name)
+		return client.NewSignerRegistry().Build(name, appConfig.Signers)
+	}
+
+	name, _ := flags.GetString(options.OAuth2FlagName)
+	if name == "" && header.Get("Authorization") == "" {
+		name = oauth2ForURL(url, appConfig)
+	}
+	if name != "" {
+		oauthConfig, found := appConfig.OAuth2[name]
+		if !found {
+			return nil, fmt.Errorf("unknown OAuth2 configuration: %s", name)
+		}
+
+		httpClient, err := settings.BuildHTTPClient()
+		if err != nil {
+			return nil, err
+		}
+
+		logger.Printf("Using OAuth2 configuration: %s", name)
+		source, err := oauth2.NewSource(name, oauthConfig, oauth2.NewCache(cfg.tokensDir), httpClient, logger)
+		if err != nil {
+			return nil, err
+		}
+		return oauth2.NewSigner(source), nil
+	}
+
+	return client.DefaultSigner{}, nil
+}
+
+// Returns the name of the OAuth2 configuration for the first
+// alias used in the URL that has one, if any.
+func oauth2ForURL(url string, appConfig config.Config) string {
+	for _, match := range aliasPattern.FindAllStringSubmatch(url, -1) {
+		if _, found := appConfig.OAuth2[match[1]]; found {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// Returns an AWS signer using the config, overridden by the flags.
+func buildAWSigner(cmd *cobra.Command, awsConfig config.AWS, logger *log.Logger) (*client.AWSigner, error) {
+	flags := cmd.Flags()
+	opts := client.AWSOptions{
+		Profile:     awsConfig.Profile,
+		Region:      awsConfig.Region,
+		RoleARN:     awsConfig.RoleARN,
+		STSEndpoint: awsConfig.STSEndpoint,
+	}
+	service := awsConfig.Service
+	if service == "" {
+		service = client.DefaultAWSService
+	}
+
+	overrides := []struct {
+		flag  string
+		value *string
+	}{
+		{options.AWSProfileFlagName, &opts.Profile},
+		{options.AWSRegionFlagName, &opts.Region},
+		{options.AWSRoleARNFlagName, &opts.RoleARN},
+		{options.AWSSTSEndpointFlagName, &opts.STSEndpoint},
+		{options.AWSServiceFlagName, &service},
+	}
+	for _, override := range overrides {
+		if flags.Changed(override.flag) {
+			*override.value, _ = flags.GetString(override.flag)
+		}
+	}
+
+	creds, region, err := client.LoadAWSCredentials(opts)
+	if err != nil {
+		return nil, err
+	}
+	if region == "" {
+		region = defaultAWSRegion
+	}
+	if opts.RoleARN != "" {
+		logger.Printf("Assuming role: %s", opts.RoleARN)
+	}
+
+	unsignedPayload, _ := flags.GetBool(options.AWSUnsignedPayloadFlagName)
+	sgn := v4.NewSigner(creds, func(s *v4.Signer) {
+		s.UnsignedPayload = unsignedPayload
+	})
+
+	logger.Printf("Signing request using Sig V4 (service: %s, region: %s)", service, region)
+	signer := client.NewAWSigner(sgn, service, region)
+	if presign, _ := flags.GetDuration(options.AWSPresignFlagName); presign > 0 {
+		signer = signer.WithPresign(presign)
+	}
+	return signer, nil
+}
+
+// Parses credentials in the format user:password.
+// If the password is omitted the user is prompted for it.
+func parseCredentials(s string, prompt passwordPrompt) (string, string, error) {
+	username, password, found := strings.Cut(s, ":")
+	if username == "" {
+		return "", "", fmt.Errorf("missing username in credentials")
+	}
+
+	if found {
+		return username, password, nil
+	}
+
+	password, err := prompt(username)
+	return username, password, err
+}
+
+// Returns a prompt that reads the password from the terminal without echo.
+func terminalPasswordPrompt(output io.Writer) passwordPrompt {
+	return func(username string) (string, error) {
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			return "", fmt.Errorf("password for %s must be given since stdin is not a terminal", username)
+		}
+
+		fmt.Fprintf(output, "Password for %s: ", username)
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(output)
+		return string(b), err
+	}
+}
+
+// Adds the flags for authentication.
+func addAuthFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.BoolP(
+		options.AWSSigV4FlagName,
+		"4",
+		false,
+		`Use AWS signature V4 as authentication in the request. Credentials are
+resolved as by the AWS CLI: from environment variables, the shared
+config and credentials files, or instance metadata.`)
+	flags.String(options.AWSRegionFlagName, "", `The AWS region to use in the AWS signature.
+Defaults to AWS_REGION, the region of the profile or `+defaultAWSRegion+`.`)
+	flags.String(options.AWSProfileFlagName, "", "The profile in the shared AWS config and credentials files to use.")
+	flags.String(options.AWSServiceFlagName, client.DefaultAWSService, "The AWS service name to use in the AWS signature, e.g. s3 or lambda.")
+	flags.String(options.AWSRoleARNFlagName, "", "Assume this role using the resolved credentials.")
+	flags.String(options.AWSSTSEndpointFlagName, "", "The STS endpoint to use when assuming a role.")
+	flags.Bool(options.AWSUnsignedPayloadFlagName, false, "Do not include the payload in the AWS signature.")
+	flags.Duration(options.AWSPresignFlagName, 0, `Output a presigned URL, valid for the given duration,
+instead of sending the request.`)
+
+	flags.StringP(options.AuthFlagName, "a", "", `Credentials in the format "user:password".
+The password is prompted for if omitted.`)
+	flags.String(options.AuthTypeFlagName, authTypeBasic, "Authentication type used with --auth: basic or digest.")
+
+	flags.String(options.APIKeyFlagName, "", "Set API key in a header or query parameter.")
+	flags.String(options.APIKeyNameFlagName, "X-API-Key", "Name of the header or query parameter for --api-key.")
+	flags.String(options.APIKeyInFlagName, string(client.APIKeyInHeader), "Where to set the API key: header or query.")
+
+	flags.String(options.OAuth2FlagName, "", `Use the named OAuth2 configuration to obtain an access token.
+By default the configuration with the same name as an alias in the URL is used.`)
+	flags.String(options.SignFlagName, "", "Sign the request using the named signer in the configuration file.")
+
+	cmd.MarkFlagsMutuallyExclusive(
+		options.AWSSigV4FlagName,
+		options.AuthFlagName,
+		options.APIKeyFlagName,
+		options.BearerFlagName,
+		options.OAuth2FlagName,
+		options.SignFlagName,
+	)
+}
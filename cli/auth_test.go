@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lunjon/http/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCredentials(t *testing.T) {
+	prompted := false
+	prompt := func(username string) (string, error) {
+		prompted = true
+		return "prompted", nil
+	}
+
+	tests := []struct {
+		value    string
+		username string
+		password string
+		prompted bool
+		wantErr  bool
+	}{
+		{"user:pass", "user", "pass", false, false},
+		{"user:pa:ss", "user", "pa:ss", false, false},
+		{"user:", "user", "", false, false},
+		{"user", "user", "prompted", true, false},
+		{":pass", "", "", false, true},
+		{"", "", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			prompted = false
+			username, password, err := parseCredentials(tt.value, prompt)
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Equal(t, tt.username, username)
+			require.Equal(t, tt.password, password)
+			require.Equal(t, tt.prompted, prompted)
+		})
+	}
+}
+
+func TestParseCredentialsPromptError(t *testing.T) {
+	prompt := func(username string) (string, error) {
+		return "", fmt.Errorf("no terminal")
+	}
+
+	_, _, err := parseCredentials("user", prompt)
+	require.Error(t, err)
+}
+
+func TestRequestWithAuthFlags(t *testing.T) {
+	fixture := setupCommandTest("get", testServer.URL+"/digest", "--auth", "user:pass", "--auth-type", "digest")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, fixture.infos.String(), "authorized")
+
+	fixture = setupCommandTest("get", testServer.URL, "--auth", "user:pass", "--api-key", "key")
+	err = fixture.cmd.Execute()
+	require.Error(t, err)
+}
+
+func TestOAuth2ForURL(t *testing.T) {
+	appConfig := config.New()
+	appConfig.OAuth2["api"] = config.OAuth2{}
+
+	require.Equal(t, "api", oauth2ForURL("{api}/path", appConfig))
+	require.Equal(t, "api", oauth2ForURL("{other}/{api}/path", appConfig))
+	require.Equal(t, "", oauth2ForURL("{other}/path", appConfig))
+	require.Equal(t, "", oauth2ForURL("localhost/api", appConfig))
+}
@@ -22,6 +22,10 @@ type cliConfig struct {
 	errors      io.Writer
 	configPath  string
 	historyPath string
+	sessionsDir string
+	tokensDir   string
+	// fail is called with the exit status when a request fails with --fail.
+	fail FailFunc
 }
 
 func (cfg cliConfig) getAppConfig() (config.Config, error) {
@@ -38,6 +42,7 @@ func (cfg cliConfig) getAppConfig() (config.Config, error) {
 const (
 	defaultTimeout   = time.Second * 30
 	defaultAWSRegion = "eu-west-1"
+	sslKeyLogFileEnv = "SSLKEYLOGFILE"
 )
 
 var (
@@ -54,15 +59,22 @@ func Build(version string) (*cobra.Command, error) {
 	configDir := path.Join(homedir, ".config", "httpcli")
 	configFilepath := path.Join(configDir, "config.toml")
 	historyPath := path.Join(configDir, ".history")
+	sessionsDir := path.Join(configDir, "sessions")
+	tokensDir := path.Join(configDir, "tokens")
 
 	cfg := cliConfig{
 		configPath:  configFilepath,
 		historyPath: historyPath,
+		sessionsDir: sessionsDir,
+		tokensDir:   tokensDir,
 		infos:       os.Stdout,
 		logs:        os.Stderr,
 		errors:      os.Stderr,
+		fail:        os.Exit,
 	}
-	return build(version, cfg), nil
+	root := build(version, cfg)
+	root.SetArgs(methodArgs(root, os.Args[1:]))
+	return root, nil
 }
 
 func checkErr(err error, output io.Writer) {
@@ -72,3 +84,7 @@ func checkErr(err error, output io.Writer) {
 	fmt.Fprintf(output, "%s: %v\n", style.RedB.Render("error"), err)
 	os.Exit(1)
 }
+
+func printWarning(output io.Writer, msg string) {
+	fmt.Fprintf(output, "%s: %s\n", style.YellowB.Render("warning"), msg)
+}
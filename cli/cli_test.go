@@ -1,14 +1,21 @@
 package cli
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/lunjon/http/internal/client"
 	"github.com/lunjon/http/internal/config"
 	"github.com/lunjon/http/internal/history"
 	"github.com/spf13/cobra"
@@ -20,6 +27,8 @@ var (
 	testdir         = "test-http"
 	testConfigPath  = path.Join(testdir, "config.toml")
 	testHistoryPath = path.Join(testdir, "history")
+	testSessionsDir = path.Join(testdir, "sessions")
+	testTokensDir   = path.Join(testdir, "tokens")
 )
 
 type signerMock struct {
@@ -51,6 +60,25 @@ func (s *serverHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch r.URL.Path {
 	case "/error":
 		w.WriteHeader(http.StatusInternalServerError)
+	case "/login":
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret"})
+		http.Redirect(w, r, "/me", http.StatusFound)
+	case "/login-failed":
+		http.SetCookie(w, &http.Cookie{Name: "csrf", Value: "rotated"})
+		w.WriteHeader(http.StatusUnauthorized)
+	case "/digest":
+		if !strings.HasPrefix(r.Header.Get("Authorization"), `Digest username="user"`) {
+			w.Header().Set("WWW-Authenticate", `Digest realm="test", qop="auth", nonce="abc"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"authorized": true}`))
+	case "/me":
+		if c, err := r.Cookie("session"); err != nil || c.Value != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"name": "me"}`))
 	default:
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{"body": true}`))
@@ -83,29 +111,32 @@ type commandTestFixture struct {
 	infos *strings.Builder
 	errs  *strings.Builder
 	cmd   *cobra.Command
+	// status is the exit status of a failed request, if any
+	status int
 }
 
 func setupCommandTest(args ...string) *commandTestFixture {
-	logs := &strings.Builder{}
-	infos := &strings.Builder{}
-	errs := &strings.Builder{}
+	fixture := &commandTestFixture{
+		logs:  &strings.Builder{},
+		infos: &strings.Builder{},
+		errs:  &strings.Builder{},
+	}
 
 	cliconf := cliConfig{
-		configPath: testConfigPath,
-		logs:       logs,
-		infos:      infos,
-		errors:     errs,
+		configPath:  testConfigPath,
+		sessionsDir: testSessionsDir,
+		tokensDir:   testTokensDir,
+		logs:        fixture.logs,
+		infos:       fixture.infos,
+		errors:      fixture.errs,
+		fail: func(status int) {
+			fixture.status = status
+		},
 	}
 
-	cmd := build("test", cliconf)
-	cmd.SetArgs(args)
-
-	return &commandTestFixture{
-		logs:  logs,
-		infos: infos,
-		errs:  errs,
-		cmd:   cmd,
-	}
+	fixture.cmd = build("test", cliconf)
+	fixture.cmd.SetArgs(methodArgs(fixture.cmd, args))
+	return fixture
 }
 
 func TestDefaultBuild(t *testing.T) {
@@ -133,3 +164,408 @@ func TestRequestGetSigned(t *testing.T) {
 	require.NotEmpty(t, fixture.infos)
 	require.Empty(t, fixture.errs)
 }
+
+func TestRequestWithSession(t *testing.T) {
+	fixture := setupCommandTest("get", testServer.URL+"/login", "--session", "test", "-H", "X-Test: value", "-H", "X-Other: value", "--session-header", "x-test")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, fixture.infos.String(), `"name": "me"`)
+
+	// The cookie and header should be stored in the session
+	fixture = setupCommandTest("session", "show", "test")
+	err = fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, fixture.infos.String(), "secret")
+	require.Contains(t, fixture.infos.String(), "X-Test")
+	require.NotContains(t, fixture.infos.String(), "X-Other")
+
+	fixture = setupCommandTest("session", "clear", "test")
+	err = fixture.cmd.Execute()
+	require.NoError(t, err)
+}
+
+func TestRequestWithSessionAndFail(t *testing.T) {
+	fixture := setupCommandTest("get", testServer.URL+"/login-failed", "--session", "test-fail", "--fail")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Equal(t, 1, fixture.status)
+
+	// The cookie of the failed request should be stored in the session
+	fixture = setupCommandTest("session", "show", "test-fail")
+	err = fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, fixture.infos.String(), "rotated")
+
+	fixture = setupCommandTest("session", "clear", "test-fail")
+	err = fixture.cmd.Execute()
+	require.NoError(t, err)
+}
+
+func TestRequestPresigned(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKIAKIAKAI")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "abcd//efgh/ijklmnopq//bca")
+	fixture := setupCommandTest("get", testServer.URL, "--aws-sigv4", "--aws-service", "s3", "--aws-presign", "5m")
+
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, fixture.infos.String(), "X-Amz-Signature=")
+	require.Contains(t, fixture.infos.String(), "%2Fs3%2Faws4_request")
+}
+
+func TestRequestWithResolve(t *testing.T) {
+	u, err := url.Parse(testServer.URL)
+	require.NoError(t, err)
+
+	fixture := setupCommandTest("get", "http://api.test:"+u.Port(), "--resolve", "api.test:"+u.Port()+":127.0.0.1", "--tls-trace")
+	err = fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, fixture.logs.String(), "Resolved api.test to 127.0.0.1")
+}
+
+func TestRequestWithUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "test.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(testServer.Config.Handler)
+	srv.Listener = listener
+	srv.Start()
+	defer srv.Close()
+
+	fixture := setupCommandTest("get", "unix:"+socket+":/path")
+	err = fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, fixture.infos.String(), `{"body": true}`)
+}
+
+func TestRequestWithProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("proxied " + r.URL.String()))
+	}))
+	defer proxy.Close()
+
+	fixture := setupCommandTest("get", "http://api.example/path", "--proxy", proxy.URL)
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, fixture.infos.String(), "proxied http://api.example/path")
+
+	fixture = setupCommandTest("get", testServer.URL, "--proxy", proxy.URL, "--no-proxy", "127.0.0.1")
+	err = fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.NotContains(t, fixture.infos.String(), "proxied")
+}
+
+func TestRequestWithInsecureTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(testServer.Config.Handler)
+	defer srv.Close()
+
+	fixture := setupCommandTest("get", srv.URL, "--tls-skip-verify-insecure")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, fixture.errs.String(), "NOT verified")
+	require.Contains(t, fixture.infos.String(), `{"body": true}`)
+}
+
+func TestRequestWithTiming(t *testing.T) {
+	fixture := setupCommandTest("get", testServer.URL+"/login", "--timing")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+
+	logs := fixture.logs.String()
+	require.Contains(t, logs, "TTFB")
+	require.Contains(t, logs, "GET "+testServer.URL+"/login")
+	require.Contains(t, logs, "GET "+testServer.URL+"/me")
+	require.NotContains(t, fixture.infos.String(), "TTFB")
+}
+
+func TestRequestWithTimingJSON(t *testing.T) {
+	fixture := setupCommandTest("get", testServer.URL+"/login", "--timing=json")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+
+	var timings []map[string]any
+	err = json.Unmarshal([]byte(fixture.logs.String()), &timings)
+	require.NoError(t, err)
+	require.Len(t, timings, 2)
+	require.Equal(t, float64(http.StatusFound), timings[0]["status"])
+	require.Contains(t, timings[1], "transfer_ms")
+}
+
+func TestRequestWithRedirects(t *testing.T) {
+	fixture := setupCommandTest("get", testServer.URL+"/login", "--format", "json", "-v")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+
+	var output struct {
+		Redirects []map[string]any `json:"redirects"`
+	}
+	err = json.Unmarshal([]byte(fixture.infos.String()), &output)
+	require.NoError(t, err)
+	require.Len(t, output.Redirects, 1)
+	require.Equal(t, float64(http.StatusFound), output.Redirects[0]["status"])
+	require.Equal(t, "/me", output.Redirects[0]["location"])
+	require.Contains(t, output.Redirects[0], "total_ms")
+	require.Contains(t, fixture.logs.String(), "Redirected: 302 GET "+testServer.URL+"/login -> /me")
+}
+
+func TestRequestWithMaxRedirects(t *testing.T) {
+	fixture := setupCommandTest("get", testServer.URL+"/login", "--max-redirects", "0", "--format", "json")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, fixture.infos.String(), `"statusCode": 302`)
+	require.NotContains(t, fixture.infos.String(), `"redirects"`)
+}
+
+func TestRequestWithCompression(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Echo the decoded request body, compressed with zstd
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body, _ = io.ReadAll(gz)
+		}
+
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "zstd") {
+			w.Write(body)
+			return
+		}
+
+		compressed, _ := client.Compress("zstd", body)
+		w.Header().Set("Content-Encoding", "zstd")
+		w.Write(compressed)
+	}))
+	defer srv.Close()
+
+	fixture := setupCommandTest("post", srv.URL, "--data", "compressed", "--compress-body", "gzip", "--compressed", "-v")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Equal(t, "compressed\r\n", fixture.infos.String())
+	require.Contains(t, fixture.logs.String(), "Compressed request body with gzip")
+	require.Contains(t, fixture.logs.String(), "10 bytes decoded (zstd)")
+}
+
+func TestRequestWithItems(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"query":       r.URL.RawQuery,
+			"header":      r.Header.Get("X-Custom"),
+			"contentType": r.Header.Get("Content-Type"),
+			"body":        string(body),
+		})
+	}))
+	defer srv.Close()
+
+	fixture := setupCommandTest("post", srv.URL, "X-Custom:value", "q==search", "user[name]=x", "user[tags][]=a", "count:=2")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+
+	var output map[string]string
+	err = json.Unmarshal([]byte(fixture.infos.String()), &output)
+	require.NoError(t, err)
+	require.Equal(t, "q=search", output["query"])
+	require.Equal(t, "value", output["header"])
+	require.Equal(t, "application/json", output["contentType"])
+	require.JSONEq(t, `{"user":{"name":"x","tags":["a"]},"count":2}`, output["body"])
+}
+
+func TestRequestWithForm(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		content, _ := io.ReadAll(file)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"name":        r.FormValue("name"),
+			"filename":    header.Filename,
+			"contentType": header.Header.Get("Content-Type"),
+			"content":     string(content),
+		})
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "image.bin")
+	require.NoError(t, os.WriteFile(file, []byte("image"), 0644))
+
+	fixture := setupCommandTest("post", srv.URL, "--form", "name=value", "--form", "file=@"+file+";type=image/png;filename=x.png")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+
+	var output map[string]string
+	err = json.Unmarshal([]byte(fixture.infos.String()), &output)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"name":        "value",
+		"filename":    "x.png",
+		"contentType": "image/png",
+		"content":     "image",
+	}, output)
+}
+
+func TestRequestWithQuery(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "query.txt")
+	require.NoError(t, os.WriteFile(file, []byte("page=2\n"), 0644))
+
+	fixture := setupCommandTest("get", srv.URL+"/search?x=1", "--query", "q=a b&c", "--query-file", file, "lang==sv", "-v")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Equal(t, "x=1&page=2&q=a+b%26c&lang=sv", query)
+	require.Contains(t, fixture.logs.String(), srv.URL+"/search?x=1&page=2&q=a+b%26c&lang=sv")
+
+	// Parameters are sent in the order given, not sorted
+	fixture = setupCommandTest("get", srv.URL, "--query", "b=1", "--query", "a=2", "z==3", "a==4")
+	err = fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Equal(t, "b=1&a=2&z=3&a=4", query)
+}
+
+func TestRequestWithDataURLEncode(t *testing.T) {
+	var form url.Values
+	var contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		r.ParseForm()
+		form = r.PostForm
+	}))
+	defer srv.Close()
+
+	fixture := setupCommandTest("post", srv.URL, "--data", "a=1", "--data-urlencode", "q=x&y z", "--data-urlencode", "a=2")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Equal(t, "application/x-www-form-urlencoded", contentType)
+	require.Equal(t, url.Values{"a": {"1", "2"}, "q": {"x&y z"}}, form)
+}
+
+func TestRequestWithContentType(t *testing.T) {
+	var contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--data", `{"a": 1}`}, "application/json"},
+		{[]string{"--data", "name: x"}, "application/yaml"},
+		{[]string{"--data", "a=1", "--json"}, "application/json"},
+		{[]string{"--data", "a", "--xml"}, "application/xml"},
+		{[]string{"--data", "a", "--content-type", "text/csv; charset=utf-8"}, "text/csv; charset=utf-8"},
+	}
+
+	for _, test := range tests {
+		fixture := setupCommandTest(append([]string{"post", srv.URL}, test.args...)...)
+		err := fixture.cmd.Execute()
+		require.NoError(t, err)
+		require.Equal(t, test.want, contentType, test.args)
+	}
+}
+
+func TestRequestWithStreamedFile(t *testing.T) {
+	var length int
+	var expect string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expect = r.Header.Get("Expect")
+		b, _ := io.ReadAll(r.Body)
+		length = len(b)
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "large.bin")
+	require.NoError(t, os.WriteFile(file, make([]byte, 2<<20), 0644))
+
+	fixture := setupCommandTest("post", srv.URL, "--data-file", file, "--progress", "--expect-continue")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Equal(t, 2<<20, length)
+	require.Equal(t, "100-continue", expect)
+	require.Contains(t, fixture.logs.String(), "Uploaded 2.0 MiB of 2.0 MiB (100%)")
+}
+
+func TestRequestWithAnyMethod(t *testing.T) {
+	var method, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		args       []string
+		wantMethod string
+		wantBody   string
+	}{
+		{[]string{"request", srv.URL}, "GET", ""},
+		{[]string{"request", "-X", "propfind", srv.URL}, "PROPFIND", ""},
+		{[]string{"request", "--request", "QUERY", srv.URL, "--data", "select *"}, "QUERY", "select *"},
+		{[]string{"PURGE", srv.URL}, "PURGE", ""},
+		{[]string{"MKCOL", srv.URL, "-v", "--data", "x"}, "MKCOL", "x"},
+		{[]string{"-v", "PURGE", srv.URL}, "PURGE", ""},
+		{[]string{"--verbose", "QUERY", "--data", "q", srv.URL}, "QUERY", "q"},
+		{[]string{"-T", "5s", "PROPFIND", srv.URL}, "PROPFIND", ""},
+		{[]string{"-vT", "5s", "PROPFIND", srv.URL}, "PROPFIND", ""},
+		{[]string{"--timeout=5s", "LOCK", srv.URL}, "LOCK", ""},
+		{[]string{"head", srv.URL, "--data", "in head"}, "HEAD", "in head"},
+		{[]string{"get", srv.URL, "--data", "in get"}, "GET", "in get"},
+		{[]string{"delete", srv.URL, "id=1"}, "DELETE", `{"id":"1"}`},
+	}
+
+	for _, test := range tests {
+		method, body = "", ""
+		fixture := setupCommandTest(test.args...)
+		err := fixture.cmd.Execute()
+		require.NoError(t, err)
+		require.Equal(t, test.wantMethod, method, test.args)
+		require.Equal(t, test.wantBody, body, test.args)
+	}
+}
+
+func TestRootWithUnknownCommand(t *testing.T) {
+	fixture := setupCommandTest("frob", "localhost")
+	err := fixture.cmd.Execute()
+	require.ErrorContains(t, err, `unknown command "frob"`)
+
+	fixture = setupCommandTest("-v", "gte", "localhost")
+	err = fixture.cmd.Execute()
+	require.ErrorContains(t, err, `unknown command "gte"`)
+	require.ErrorContains(t, err, "Did you mean this?\n\tget")
+
+	fixture = setupCommandTest("--bogus")
+	err = fixture.cmd.Execute()
+	require.ErrorContains(t, err, `unknown flag: --bogus`)
+
+	fixture = setupCommandTest("--bogus", "PURGE", "localhost")
+	err = fixture.cmd.Execute()
+	require.Error(t, err)
+}
+
+func TestRequestWithHTTP2PriorKnowledge(t *testing.T) {
+	srv := httptest.NewUnstartedServer(testServer.Config.Handler)
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	defer srv.Close()
+
+	fixture := setupCommandTest("get", srv.URL, "--http2-prior-knowledge", "--format", "json", "-v")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, fixture.infos.String(), `"protocol": "HTTP/2.0"`)
+	require.Contains(t, fixture.logs.String(), "Protocol: HTTP/2.0")
+}
@@ -26,6 +26,13 @@ type Formatter interface {
 	FormatHistory([]history.Entry) ([]byte, error)
 }
 
+// failingFormatter is a formatter that can tell if the response it
+// formatted is a failure, even if the status is successful.
+type failingFormatter interface {
+	Formatter
+	Failed() bool
+}
+
 type NullFormatter struct{}
 
 func (f NullFormatter) FormatResponse(*http.Response) ([]byte, error) { return nil, nil }
@@ -86,11 +93,14 @@ func (f *jsonFormatter) FormatResponse(r *http.Response) ([]byte, error) {
 	output := struct {
 		Status     string            `json:"status,omitempty"`
 		StatusCode int               `json:"statusCode,omitempty"`
+		Protocol   string            `json:"protocol,omitempty"`
 		Headers    map[string]string `json:"headers,omitempty"`
 		Body       *string           `json:"body,omitempty"`
+		Redirects  []client.Timing   `json:"redirects,omitempty"`
 	}{
 		Status:     r.Status,
 		StatusCode: r.StatusCode,
+		Protocol:   r.Proto,
 		Headers:    headerToMap(r.Header),
 	}
 
@@ -104,6 +114,10 @@ func (f *jsonFormatter) FormatResponse(r *http.Response) ([]byte, error) {
 		output.Body = &b
 	}
 
+	if hops := client.Hops(r); len(hops) > 1 {
+		output.Redirects = hops[:len(hops)-1]
+	}
+
 	return json.MarshalIndent(output, "", " ")
 }
 
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/lunjon/http/cli/options"
+	"github.com/lunjon/http/internal/graphql"
+	"github.com/lunjon/http/internal/history"
+	"github.com/lunjon/http/internal/style"
+	"github.com/spf13/cobra"
+)
+
+const graphqlAccept = "application/graphql-response+json, application/json"
+
+func buildGraphQL(cfg cliConfig) *cobra.Command {
+	connOpts := newConnectionOptions()
+
+	cmd := &cobra.Command{
+		Use:   "graphql <url> [items...]",
+		Short: "Send a GraphQL request",
+		Long: `Send a GraphQL query or mutation as a POST request with a JSON body.
+
+The data of the response is written to stdout, and errors to stderr.
+With --fail, the exit code is also non-zero if the response has errors.
+
+Use --introspect to write the schema of the server in SDL.
+
+Headers and query parameters can be given as request items after the URL,
+e.g. Authorization:"Bearer token" or key==value.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			flags := cmd.Flags()
+			req, err := buildGraphQLRequest(cmd)
+			checkErr(err, cfg.errors)
+
+			body, err := json.Marshal(req)
+			checkErr(err, cfg.errors)
+
+			format, _ := flags.GetString(options.FormatFlagName)
+			introspect, _ := flags.GetBool(options.IntrospectFlagName)
+			runRequest(cmd, args, cfg, connOpts, requestInput{
+				method: http.MethodPost,
+				data:   options.NewDataOptions(string(body), "", false, nil),
+				header: http.Header{"Accept": {graphqlAccept}},
+				formatter: &graphqlFormatter{
+					format:     Format(format),
+					introspect: introspect,
+					errors:     cfg.errors,
+				},
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.String(options.QueryFlagName, "", "GraphQL document with the query or mutation.")
+	flags.String(options.QueryFileFlagName, "", "Read the GraphQL document from file.")
+	cmd.MarkFlagFilename(options.QueryFileFlagName, "graphql", "gql")
+	flags.String(options.VariablesFlagName, "", "Variables of the operation, as a JSON object.")
+	flags.String(options.OperationFlagName, "", "Name of the operation to execute, if the document has several.")
+	flags.Bool(options.IntrospectFlagName, false, "Write the schema of the server in SDL, using an introspection query.")
+	cmd.MarkFlagsMutuallyExclusive(options.QueryFlagName, options.QueryFileFlagName, options.IntrospectFlagName)
+	cmd.MarkFlagsOneRequired(options.QueryFlagName, options.QueryFileFlagName, options.IntrospectFlagName)
+
+	addConnectionFlags(cmd, connOpts)
+	addCommonFlags(cmd)
+	return cmd
+}
+
+// Returns the GraphQL request given by the flags.
+func buildGraphQLRequest(cmd *cobra.Command) (graphql.Request, error) {
+	flags := cmd.Flags()
+	if introspect, _ := flags.GetBool(options.IntrospectFlagName); introspect {
+		return graphql.NewRequest(graphql.IntrospectionQuery, graphql.IntrospectionOperation, "")
+	}
+
+	query, _ := flags.GetString(options.QueryFlagName)
+	if file, _ := flags.GetString(options.QueryFileFlagName); file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return graphql.Request{}, err
+		}
+		query = string(b)
+	}
+
+	operation, _ := flags.GetString(options.OperationFlagName)
+	variables, _ := flags.GetString(options.VariablesFlagName)
+	return graphql.NewRequest(query, operation, variables)
+}
+
+// graphqlFormatter outputs the data of GraphQL responses, or the schema
+// when introspecting, and writes the errors of the response to stderr.
+// Responses that are not GraphQL are output as by the format.
+type graphqlFormatter struct {
+	format     Format
+	introspect bool
+	errors     io.Writer
+	failed     bool
+}
+
+func (f *graphqlFormatter) FormatResponse(r *http.Response) ([]byte, error) {
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	res, err := graphql.ParseResponse(body)
+	if err != nil || f.format == JSONFormat {
+		if err == nil {
+			f.failed = len(res.Errors) > 0
+		}
+
+		formatter, err := FormatterFromString(f.format)
+		if err != nil {
+			return nil, err
+		}
+		return formatter.FormatResponse(r)
+	}
+
+	f.failed = len(res.Errors) > 0
+	for _, e := range res.Errors {
+		fmt.Fprintf(f.errors, "%s: %s\n", style.RedB.Render("error"), e)
+	}
+
+	if f.introspect && !f.failed {
+		sdl, err := graphql.PrintSchema(res.Data)
+		return []byte(strings.TrimSuffix(sdl, "\n")), err
+	}
+
+	if len(res.Data) == 0 || string(res.Data) == "null" {
+		return nil, nil
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err = json.Indent(buf, res.Data, "", "  ")
+	return buf.Bytes(), err
+}
+
+func (f *graphqlFormatter) FormatHistory([]history.Entry) ([]byte, error) {
+	return nil, nil
+}
+
+func (f *graphqlFormatter) Failed() bool {
+	return f.failed
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lunjon/http/internal/graphql"
+	"github.com/stretchr/testify/require"
+)
+
+func newGraphQLServer(t *testing.T, handle func(graphql.Request) string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, graphqlAccept, r.Header.Get("Accept"))
+
+		var req graphql.Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set("Content-Type", "application/graphql-response+json")
+		io.WriteString(w, handle(req))
+	}))
+}
+
+func TestGraphQL(t *testing.T) {
+	var received graphql.Request
+	srv := newGraphQLServer(t, func(req graphql.Request) string {
+		received = req
+		return `{"data": {"user": {"name": "meow"}}}`
+	})
+	defer srv.Close()
+
+	fixture := setupCommandTest("graphql", srv.URL,
+		"--query", "query User($id: ID!) { user(id: $id) { name } }",
+		"--variables", `{"id": "1"}`,
+		"--operation", "User")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+
+	require.Equal(t, "query User($id: ID!) { user(id: $id) { name } }", received.Query)
+	require.Equal(t, "User", received.OperationName)
+	require.JSONEq(t, `{"id": "1"}`, string(received.Variables))
+	require.Equal(t, "{\n  \"user\": {\n    \"name\": \"meow\"\n  }\n}\r\n", fixture.infos.String())
+}
+
+func TestGraphQLQueryFile(t *testing.T) {
+	var query string
+	srv := newGraphQLServer(t, func(req graphql.Request) string {
+		query = req.Query
+		return `{"data": null, "errors": [{"message": "not allowed", "path": ["me"]}]}`
+	})
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "me.graphql")
+	require.NoError(t, os.WriteFile(file, []byte("{ me { name } }"), 0644))
+
+	fixture := setupCommandTest("graphql", srv.URL, "--query-file", file)
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Equal(t, "{ me { name } }", query)
+	require.Empty(t, fixture.infos.String())
+	require.Contains(t, fixture.errs.String(), "not allowed at me")
+}
+
+func TestGraphQLIntrospect(t *testing.T) {
+	srv := newGraphQLServer(t, func(req graphql.Request) string {
+		require.Equal(t, graphql.IntrospectionQuery, req.Query)
+		return `{"data": {"__schema": {
+			"queryType": {"name": "Query"},
+			"types": [{"kind": "OBJECT", "name": "Query", "fields": [
+				{"name": "ok", "args": [], "type": {"kind": "SCALAR", "name": "Boolean"}}
+			]}]
+		}}}`
+	})
+	defer srv.Close()
+
+	fixture := setupCommandTest("graphql", srv.URL, "--introspect")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Equal(t, "type Query {\n  ok: Boolean\n}\r\n", fixture.infos.String())
+}
+
+func TestGraphQLFormatterFailed(t *testing.T) {
+	tests := []struct {
+		body   string
+		format Format
+		failed bool
+	}{
+		{`{"data": {"ok": true}}`, TextFormat, false},
+		{`{"data": null, "errors": [{"message": "boom"}]}`, TextFormat, true},
+		{`{"data": null, "errors": [{"message": "boom"}]}`, JSONFormat, true},
+		{`not graphql`, TextFormat, false},
+	}
+
+	for _, test := range tests {
+		errs := &strings.Builder{}
+		f := &graphqlFormatter{format: test.format, errors: errs}
+		res := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(test.body)),
+		}
+
+		_, err := f.FormatResponse(res)
+		require.NoError(t, err)
+		require.Equal(t, test.failed, f.Failed(), test.body)
+	}
+}
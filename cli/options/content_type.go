@@ -0,0 +1,31 @@
+package options
+
+import (
+	"fmt"
+	"mime"
+
+	"github.com/lunjon/http/internal/client"
+	"github.com/spf13/cobra"
+)
+
+// ContentTypeFromFlags returns the Content-Type given by --content-type,
+// or one of the shortcuts --json and --xml. It is empty if none is given,
+// in which case the type is detected from the body.
+func ContentTypeFromFlags(cmd *cobra.Command) (string, error) {
+	flags := cmd.Flags()
+	if json, _ := flags.GetBool(JSONFlagName); json {
+		return client.MIMETypeJSON.String(), nil
+	}
+	if xml, _ := flags.GetBool(XMLFlagName); xml {
+		return client.MIMETypeXML.String(), nil
+	}
+
+	contentType, _ := flags.GetString(ContentTypeFlagName)
+	if contentType == "" {
+		return "", nil
+	}
+	if _, _, err := mime.ParseMediaType(contentType); err != nil {
+		return "", fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+	return contentType, nil
+}
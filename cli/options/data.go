@@ -1,15 +1,16 @@
 package options
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path"
 	"strings"
 
 	"github.com/lunjon/http/internal/client"
 	"github.com/lunjon/http/internal/types"
-	"github.com/lunjon/http/internal/util"
 	"github.com/spf13/cobra"
 )
 
@@ -20,6 +21,8 @@ type DataOptions struct {
 	dataFile       string
 	dataStdin      bool
 	dataURLEncoded []string
+	form           []string
+	items          RequestItems
 }
 
 func NewDataOptions(dataString, dataFile string, dataStdin bool, urlEncoded []string) DataOptions {
@@ -37,58 +40,213 @@ func DataOptionsFromFlags(cmd *cobra.Command) (DataOptions, error) {
 	dataFile, _ := flags.GetString(DataFileFlagName)
 	dataStdin, _ := flags.GetBool(DataStdinFlagName)
 	dataURLEncoded, _ := flags.GetStringArray(DataURLEncodeFlagName)
+	form, _ := flags.GetStringArray(FormFlagName)
 
 	opts := DataOptions{
 		dataString:     dataString,
 		dataFile:       dataFile,
 		dataStdin:      dataStdin,
 		dataURLEncoded: dataURLEncoded,
+		form:           form,
 	}
 	return opts, nil
 }
 
+// WithItems returns the options with the fields of
+// the request items as a JSON body.
+func (opts DataOptions) WithItems(items RequestItems) DataOptions {
+	opts.items = items
+	return opts
+}
+
+// WithForm returns the options with a multipart form body,
+// given as values of the format parsed by ParseFormParts.
+func (opts DataOptions) WithForm(values []string) DataOptions {
+	opts.form = values
+	return opts
+}
+
+// GetForm returns the multipart form body, or nil if not given.
+func (opts DataOptions) GetForm() (*client.Multipart, error) {
+	if len(opts.form) == 0 {
+		return nil, nil
+	}
+	if opts.items.HasFields() {
+		return nil, fmt.Errorf("request items cannot be combined with other request body options")
+	}
+
+	parts, err := ParseFormParts(opts.form)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewMultipart(parts)
+}
+
 func (opts DataOptions) GetData() (types.Option[[]byte], client.MIMEType, error) {
 	body := types.Option[[]byte]{}
 	mime := client.MIMETypeUnknown
 
+	if opts.items.HasFields() {
+		if opts.dataString != "" || opts.dataFile != "" || opts.dataStdin || len(opts.dataURLEncoded) > 0 {
+			return body, mime, fmt.Errorf("request items cannot be combined with other request body options")
+		}
+
+		b, err := opts.items.JSON()
+		return body.Set(b), client.MIMETypeJSON, err
+	}
+
+	body, mime, err := opts.getRawData()
+	if err != nil || len(opts.dataURLEncoded) == 0 {
+		return body, mime, err
+	}
+
+	// URL encoded data is appended to any other data,
+	// which is then expected to be URL encoded as well.
+	encoded, err := encodeURLValues(opts.dataURLEncoded)
+	if err != nil {
+		return body, mime, err
+	}
+
+	b := []byte(encoded)
+	if raw, ok := body.Get(); ok && len(raw) > 0 {
+		b = append(append(raw, '&'), b...)
+	}
+	return body.Set(b), client.MIMETypeFormURLEncoded, nil
+}
+
+// streamThreshold is the size above which a file or stdin is streamed
+// when the request is sent, instead of being read into memory.
+const streamThreshold = 1 << 20
+
+// GetBody returns the body given by the options, if any, like GetData. But a
+// file or stdin larger than 1 MiB is streamed, unless combined with URL encoded
+// data. The MIME type of a stream is detected from the start of its content.
+func (opts DataOptions) GetBody() (client.Body, client.MIMEType, error) {
+	if opts.items.HasFields() || len(opts.dataURLEncoded) > 0 {
+		return opts.getBytesBody()
+	}
+
+	if opts.dataFile != "" {
+		body, err := client.NewFileBody(opts.dataFile)
+		if err != nil || body.Len() <= streamThreshold {
+			return opts.getBytesBody()
+		}
+
+		mime := client.MIMETypeFromExtension(path.Ext(opts.dataFile))
+		if mime == client.MIMETypeUnknown {
+			mime, err = detectFileMIMEType(opts.dataFile)
+		}
+		return body, mime, err
+	}
+
+	if opts.dataStdin {
+		return readStream(os.Stdin)
+	}
+	return opts.getBytesBody()
+}
+
+func (opts DataOptions) getBytesBody() (client.Body, client.MIMEType, error) {
+	data, mime, err := opts.GetData()
+	if err != nil || !data.IsSome() {
+		return nil, mime, err
+	}
+	return client.BytesBody(data.MustGet()), mime, nil
+}
+
+// Reads r into memory if it is no larger than streamThreshold,
+// or else returns a stream of the content.
+func readStream(r io.Reader) (client.Body, client.MIMEType, error) {
+	start, err := io.ReadAll(io.LimitReader(r, streamThreshold+1))
+	if err != nil {
+		return nil, client.MIMETypeUnknown, err
+	}
+	if len(start) <= streamThreshold {
+		return client.BytesBody(start), client.DetectMIMEType(start), nil
+	}
+
+	mime := client.DetectStreamMIMEType(start[:sniffLen])
+	return client.NewStreamBody(io.MultiReader(bytes.NewReader(start), r)), mime, nil
+}
+
+// sniffLen is the number of bytes used to detect the MIME type
+// of streamed content, as done by http.DetectContentType.
+const sniffLen = 512
+
+func detectFileMIMEType(path string) (client.MIMEType, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return client.MIMETypeUnknown, err
+	}
+	defer f.Close()
+
+	start := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, start)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return client.MIMETypeUnknown, err
+	}
+	return client.DetectStreamMIMEType(start[:n]), nil
+}
+
+// Returns the data given as string, file or stdin. The MIME type is given by
+// the extension of a file, or else detected from the content of the data.
+func (opts DataOptions) getRawData() (types.Option[[]byte], client.MIMEType, error) {
+	body := types.Option[[]byte]{}
+	mime := client.MIMETypeUnknown
+
+	var b []byte
 	if opts.dataString != "" {
-		return body.Set([]byte(opts.dataString)), mime, nil
+		b = []byte(opts.dataString)
 	} else if opts.dataFile != "" {
-		b, err := os.ReadFile(opts.dataFile)
+		var err error
+		b, err = os.ReadFile(opts.dataFile)
 		if err != nil {
 			return body, mime, err
 		}
-
-		// Try detecting filetype in order to set MIME type
-		switch path.Ext(opts.dataFile) {
-		case ".html":
-			mime = client.MIMETypeHTML
-		case ".csv":
-			mime = client.MIMETypeCSV
-		case ".json":
-			mime = client.MIMETypeJSON
-		case ".xml":
-			mime = client.MIMETypeXML
-		}
-		return body.Set(b), mime, nil
+		mime = client.MIMETypeFromExtension(path.Ext(opts.dataFile))
 	} else if opts.dataStdin {
-		b, err := io.ReadAll(os.Stdin)
-		return body.Set(b), mime, err
-	} else if len(opts.dataURLEncoded) > 0 {
-		splitter := util.NewSplitter("=")
-		m, err := splitter.ParseMany(opts.dataURLEncoded)
+		var err error
+		b, err = io.ReadAll(os.Stdin)
 		if err != nil {
 			return body, mime, err
 		}
+	} else {
+		return body, mime, nil
+	}
 
-		values := []string{}
-		for k, v := range m {
-			values = append(values, fmt.Sprintf("%s=%s", k, v))
+	if mime == client.MIMETypeUnknown {
+		mime = client.DetectMIMEType(b)
+	}
+	return body.Set(b), mime, nil
+}
+
+// Encodes the values in order, where each value is given as
+// in curl, depending on which of = and @ that comes first:
+//
+//	name=content  name and content are encoded
+//	name@file     name and the content of the file are encoded
+//	=content      content is encoded without a name
+//	@file         content of the file is encoded without a name
+//	content       content is encoded without a name
+func encodeURLValues(values []string) (string, error) {
+	encoded := make([]string, 0, len(values))
+	for _, value := range values {
+		name, content := "", value
+		if i := strings.IndexAny(value, "=@"); i >= 0 {
+			name, content = value[:i], value[i+1:]
+			if value[i] == '@' {
+				b, err := os.ReadFile(content)
+				if err != nil {
+					return "", err
+				}
+				content = string(b)
+			}
 		}
 
-		b := strings.Join(values, "&")
-		return body.Set([]byte(b)), client.MIMETypeFormURLEncoded, nil
+		if name == "" {
+			encoded = append(encoded, url.QueryEscape(content))
+		} else {
+			encoded = append(encoded, url.QueryEscape(name)+"="+url.QueryEscape(content))
+		}
 	}
-
-	return body, mime, nil
+	return strings.Join(encoded, "&"), nil
 }
@@ -0,0 +1,116 @@
+package options
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lunjon/http/internal/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDataURLEncoded(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "message.txt")
+	require.NoError(t, os.WriteFile(file, []byte("hello & goodbye"), 0644))
+
+	tests := []struct {
+		name       string
+		dataString string
+		values     []string
+		want       string
+	}{
+		{"ordered", "", []string{"b=2", "a=1"}, "b=2&a=1"},
+		{"repeated keys", "", []string{"tag=a", "tag=b"}, "tag=a&tag=b"},
+		{"special characters", "", []string{"q=a&b=c d", "eq=x=y"}, "q=a%26b%3Dc+d&eq=x%3Dy"},
+		{"unicode", "", []string{"name=åäö"}, "name=%C3%A5%C3%A4%C3%B6"},
+		{"encoded name", "", []string{"first name=x"}, "first+name=x"},
+		{"file", "", []string{"msg@" + file}, "msg=hello+%26+goodbye"},
+		{"without name", "", []string{"=a b", "c&d"}, "a+b&c%26d"},
+		{"with data", "raw=1", []string{"a=b c"}, "raw=1&a=b+c"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, mime, err := NewDataOptions(test.dataString, "", false, test.values).GetData()
+			require.NoError(t, err)
+			require.Equal(t, test.want, string(data.MustGet()))
+			require.Equal(t, client.MIMETypeFormURLEncoded, mime)
+		})
+	}
+
+	_, _, err := NewDataOptions("", "", false, []string{"msg@does-not-exist"}).GetData()
+	require.Error(t, err)
+}
+
+func TestGetDataMIMEType(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "body.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`{"a": 1}`), 0644))
+	noExtFile := filepath.Join(dir, "body")
+	require.NoError(t, os.WriteFile(noExtFile, []byte(`{"a": 1}`), 0644))
+
+	tests := []struct {
+		name       string
+		dataString string
+		dataFile   string
+		want       client.MIMEType
+	}{
+		{"json string", `{"a": 1}`, "", client.MIMETypeJSON},
+		{"xml string", `<user><id>1</id></user>`, "", client.MIMETypeXML},
+		{"form string", `a=1&b=2`, "", client.MIMETypeFormURLEncoded},
+		{"extension before content", "", yamlFile, client.MIMETypeYAML},
+		{"content without extension", "", noExtFile, client.MIMETypeJSON},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, mime, err := NewDataOptions(test.dataString, test.dataFile, false, nil).GetData()
+			require.NoError(t, err)
+			require.Equal(t, test.want, mime)
+		})
+	}
+}
+
+func TestGetBodyStreamsLargeFile(t *testing.T) {
+	dir := t.TempDir()
+	large := filepath.Join(dir, "large.ndjson")
+	require.NoError(t, os.WriteFile(large, []byte(strings.Repeat("{\"a\":1}\n", streamThreshold)), 0644))
+	small := filepath.Join(dir, "small.json")
+	require.NoError(t, os.WriteFile(small, []byte(`{"a": 1}`), 0644))
+
+	body, mime, err := NewDataOptions("", large, false, nil).GetBody()
+	require.NoError(t, err)
+	require.IsType(t, &client.FileBody{}, body)
+	require.Equal(t, client.MIMETypeNDJSON, mime)
+
+	body, mime, err = NewDataOptions("", small, false, nil).GetBody()
+	require.NoError(t, err)
+	require.Equal(t, client.BytesBody(`{"a": 1}`), body)
+	require.Equal(t, client.MIMETypeJSON, mime)
+
+	// URL encoded data is appended to the content of the file
+	body, _, err = NewDataOptions("", large, false, []string{"a=b"}).GetBody()
+	require.NoError(t, err)
+	require.IsType(t, client.BytesBody{}, body)
+}
+
+func TestReadStream(t *testing.T) {
+	body, mime, err := readStream(strings.NewReader(`{"a": 1}`))
+	require.NoError(t, err)
+	require.Equal(t, client.BytesBody(`{"a": 1}`), body)
+	require.Equal(t, client.MIMETypeJSON, mime)
+
+	content := "[" + strings.Repeat(`{"a": 1},`, streamThreshold) + "{}]"
+	body, mime, err = readStream(strings.NewReader(content))
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), body.Len())
+	require.Equal(t, client.MIMETypeJSON, mime)
+
+	r, err := body.Open()
+	require.NoError(t, err)
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, content, string(b))
+}
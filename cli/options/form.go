@@ -0,0 +1,47 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lunjon/http/internal/client"
+)
+
+// ParseFormParts parses values of the format name=value, or
+// name=@path;type=...;filename=... for uploading a file, where
+// type and filename are optional.
+func ParseFormParts(values []string) ([]client.FormPart, error) {
+	parts := []client.FormPart{}
+	for _, value := range values {
+		name, v, found := strings.Cut(value, "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid form value %q: expected name=value or name=@file", value)
+		}
+
+		path, isFile := strings.CutPrefix(v, "@")
+		if !isFile {
+			parts = append(parts, client.FormPart{Name: name, Value: v})
+			continue
+		}
+
+		params := strings.Split(path, ";")
+		part := client.FormPart{Name: name, File: params[0]}
+		if part.File == "" {
+			return nil, fmt.Errorf("invalid form value %q: missing file", value)
+		}
+
+		for _, param := range params[1:] {
+			key, v, _ := strings.Cut(param, "=")
+			switch strings.TrimSpace(key) {
+			case "type":
+				part.ContentType = v
+			case "filename":
+				part.Filename = v
+			default:
+				return nil, fmt.Errorf("invalid form value %q: unknown parameter %q", value, key)
+			}
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
@@ -0,0 +1,31 @@
+package options
+
+import (
+	"testing"
+
+	"github.com/lunjon/http/internal/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormParts(t *testing.T) {
+	parts, err := ParseFormParts([]string{
+		"name=value",
+		"empty=",
+		"query=a=b;c",
+		"file=@path/to/file",
+		"image=@image.bin;type=image/png;filename=x.png",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []client.FormPart{
+		{Name: "name", Value: "value"},
+		{Name: "empty", Value: ""},
+		{Name: "query", Value: "a=b;c"},
+		{Name: "file", File: "path/to/file"},
+		{Name: "image", File: "image.bin", ContentType: "image/png", Filename: "x.png"},
+	}, parts)
+
+	for _, value := range []string{"", "name", "=value", "file=@", "file=@path;unknown=x"} {
+		_, err := ParseFormParts([]string{value})
+		require.Error(t, err, value)
+	}
+}
@@ -0,0 +1,238 @@
+package options
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lunjon/http/internal/client"
+)
+
+// Separators of request items, longest first for
+// those starting with the same character.
+const (
+	querySeparator     = "=="
+	rawJSONSeparator   = ":="
+	stringSeparator    = "="
+	headerSeparator    = ":"
+	fileFieldSeparator = "@"
+)
+
+var itemSeparators = []string{
+	querySeparator,
+	rawJSONSeparator,
+	stringSeparator,
+	headerSeparator,
+	fileFieldSeparator,
+}
+
+type fieldKind int
+
+const (
+	stringField fieldKind = iota
+	rawJSONField
+	fileField
+)
+
+// field is a request item for the body.
+type field struct {
+	path  []string
+	kind  fieldKind
+	value string
+}
+
+// RequestItems are given as arguments after the URL, as in HTTPie:
+//
+//	Header:value   request header
+//	key==value     query parameter
+//	key=value      string field
+//	key:=json      raw JSON field
+//	key@file       string field with the content of the file
+//
+// The key of a field can be a nested path, e.g. user[name] or tags[],
+// which appends to an array. A separator in a key is escaped with \.
+type RequestItems struct {
+	Header http.Header
+	Query  client.Query
+	fields []field
+}
+
+func ParseRequestItems(args []string) (RequestItems, error) {
+	items := RequestItems{
+		Header: http.Header{},
+		Query:  client.Query{},
+	}
+
+	for _, arg := range args {
+		key, sep, value, err := splitItem(arg)
+		if err != nil {
+			return items, err
+		}
+
+		switch sep {
+		case headerSeparator:
+			items.Header.Add(key, strings.TrimSpace(value))
+		case querySeparator:
+			items.Query = append(items.Query, client.QueryParam{Key: key, Value: value})
+		default:
+			path, err := parseFieldPath(key)
+			if err != nil {
+				return items, fmt.Errorf("invalid request item %q: %w", arg, err)
+			}
+
+			f := field{path: path, value: value}
+			switch sep {
+			case rawJSONSeparator:
+				f.kind = rawJSONField
+				if !json.Valid([]byte(value)) {
+					return items, fmt.Errorf("invalid request item %q: invalid JSON", arg)
+				}
+			case fileFieldSeparator:
+				f.kind = fileField
+			}
+			items.fields = append(items.fields, f)
+		}
+	}
+
+	return items, nil
+}
+
+// HasFields returns true if any of the items is a field of the body.
+func (items RequestItems) HasFields() bool {
+	return len(items.fields) > 0
+}
+
+// JSON returns the fields as a JSON object.
+func (items RequestItems) JSON() ([]byte, error) {
+	var root any = map[string]any{}
+	for _, f := range items.fields {
+		var value any
+		switch f.kind {
+		case stringField:
+			value = f.value
+		case rawJSONField:
+			if err := json.Unmarshal([]byte(f.value), &value); err != nil {
+				return nil, err
+			}
+		case fileField:
+			b, err := os.ReadFile(f.value)
+			if err != nil {
+				return nil, err
+			}
+			value = string(b)
+		}
+
+		var err error
+		root, err = setPath(root, f.path, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field %s: %w", strings.Join(f.path, "."), err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+// Splits the item at the first separator, where escaped
+// separator characters in the key are unescaped.
+func splitItem(item string) (string, string, string, error) {
+	key := strings.Builder{}
+	for i := 0; i < len(item); i++ {
+		if item[i] == '\\' && i+1 < len(item) {
+			i++
+			key.WriteByte(item[i])
+			continue
+		}
+
+		for _, sep := range itemSeparators {
+			if strings.HasPrefix(item[i:], sep) {
+				if key.Len() == 0 {
+					return "", "", "", fmt.Errorf("invalid request item %q: missing key", item)
+				}
+				return key.String(), sep, item[i+len(sep):], nil
+			}
+		}
+		key.WriteByte(item[i])
+	}
+	return "", "", "", fmt.Errorf("invalid request item %q: missing separator", item)
+}
+
+// Parses a key like user[tags][0] into its segments: user, tags and 0.
+func parseFieldPath(key string) ([]string, error) {
+	name, rest, _ := strings.Cut(key, "[")
+	if name == "" {
+		return nil, fmt.Errorf("missing name")
+	}
+
+	path := []string{name}
+	if rest == "" {
+		return path, nil
+	}
+
+	for _, seg := range strings.Split("["+rest, "[")[1:] {
+		seg, found := strings.CutSuffix(seg, "]")
+		if !found || strings.Contains(seg, "]") {
+			return nil, fmt.Errorf("unbalanced brackets")
+		}
+		path = append(path, seg)
+	}
+	return path, nil
+}
+
+// Sets value at path in node, which is created if nil. An empty segment
+// appends to an array. A number is an index of an array if node is an
+// array, or if it is nil and the index is 0, and else a key of an object.
+func setPath(node any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	seg, rest := path[0], path[1:]
+	arr, isArray := node.([]any)
+	index, err := strconv.Atoi(seg)
+	isIndex := seg == "" || (err == nil && (isArray || (node == nil && index == 0)))
+
+	if !isIndex {
+		m, ok := node.(map[string]any)
+		if node == nil {
+			m = map[string]any{}
+		} else if !ok {
+			return nil, fmt.Errorf("cannot set key %q on %s", seg, jsonType(node))
+		}
+
+		m[seg], err = setPath(m[seg], rest, value)
+		return m, err
+	}
+
+	if node != nil && !isArray {
+		return nil, fmt.Errorf("cannot use %s as array", jsonType(node))
+	}
+
+	if seg == "" || index == len(arr) {
+		child, err := setPath(nil, rest, value)
+		return append(arr, child), err
+	}
+
+	if index < 0 || index > len(arr) {
+		return nil, fmt.Errorf("array index out of range: %d", index)
+	}
+	arr[index], err = setPath(arr[index], rest, value)
+	return arr, err
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
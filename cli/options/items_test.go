@@ -0,0 +1,105 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRequestItems(t *testing.T) {
+	items, err := ParseRequestItems([]string{
+		"X-Custom:value",
+		"Authorization: Bearer a:b",
+		"q==search term",
+		"name=x",
+		"count:=1",
+		"url=http://example.com",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value", items.Header.Get("X-Custom"))
+	require.Equal(t, "Bearer a:b", items.Header.Get("Authorization"))
+	require.Equal(t, "search term", items.Query.Get("q"))
+	require.True(t, items.HasFields())
+
+	b, err := items.JSON()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name":"x","count":1,"url":"http://example.com"}`, string(b))
+}
+
+func TestParseRequestItemsInvalid(t *testing.T) {
+	tests := []string{
+		"missing-separator",
+		"=value",
+		"count:=invalid",
+		"user[name=x",
+		"user[name]]=x",
+		"[0]=x",
+	}
+
+	for _, item := range tests {
+		t.Run(item, func(t *testing.T) {
+			_, err := ParseRequestItems([]string{item})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestRequestItemsJSON(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bio.txt")
+	require.NoError(t, os.WriteFile(file, []byte("text from file"), 0644))
+
+	tests := []struct {
+		items []string
+		want  string
+	}{
+		{[]string{"user[name]=x", "user[age]:=30"}, `{"user":{"name":"x","age":30}}`},
+		{[]string{"tags[]=a", "tags[]=b"}, `{"tags":["a","b"]}`},
+		{[]string{"tags:=[\"a\"]", "tags[]=b"}, `{"tags":["a","b"]}`},
+		{[]string{"list[0]=a", "list[1]=b", "list[0]=c"}, `{"list":["c","b"]}`},
+		{[]string{"tags:=[\"a\"]", "tags[1]=b"}, `{"tags":["a","b"]}`},
+		// Numbers are keys, unless an index of an array
+		{[]string{"404=not found"}, `{"404":"not found"}`},
+		{[]string{"codes[200]=ok", "codes[404]=not found"}, `{"codes":{"200":"ok","404":"not found"}}`},
+		{[]string{"ids[999999999999]=x"}, `{"ids":{"999999999999":"x"}}`},
+		{[]string{"list[1]=b", "list[0]=a"}, `{"list":{"0":"a","1":"b"}}`},
+		{[]string{"users[0][name]=x", "users[0][roles][]=admin"}, `{"users":[{"name":"x","roles":["admin"]}]}`},
+		{[]string{"bio@" + file}, `{"bio":"text from file"}`},
+		{[]string{`a\=b=c`, `x\:y=z`}, `{"a=b":"c","x:y":"z"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			items, err := ParseRequestItems(test.items)
+			require.NoError(t, err)
+
+			b, err := items.JSON()
+			require.NoError(t, err)
+			require.JSONEq(t, test.want, string(b))
+		})
+	}
+
+	items, err := ParseRequestItems([]string{"name=x", "name[first]=y"})
+	require.NoError(t, err)
+	_, err = items.JSON()
+	require.ErrorContains(t, err, "cannot set key")
+
+	items, err = ParseRequestItems([]string{"list[]=a", "list[5]=b"})
+	require.NoError(t, err)
+	_, err = items.JSON()
+	require.ErrorContains(t, err, "array index out of range")
+}
+
+func TestDataOptionsWithItems(t *testing.T) {
+	items, err := ParseRequestItems([]string{"name=x"})
+	require.NoError(t, err)
+
+	data, mime, err := NewDataOptions("", "", false, nil).WithItems(items).GetData()
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"x"}`, string(data.MustGet()))
+	require.Equal(t, "application/json", mime.String())
+
+	_, _, err = NewDataOptions("body", "", false, nil).WithItems(items).GetData()
+	require.Error(t, err)
+}
@@ -2,20 +2,60 @@ package options
 
 const (
 	HeaderFlagName                = "header"
+	MethodFlagName                = "request"
 	AWSSigV4FlagName              = "aws-sigv4"
 	AWSRegionFlagName             = "aws-region"
+	AWSProfileFlagName            = "aws-profile"
+	AWSServiceFlagName            = "aws-service"
+	AWSRoleARNFlagName            = "aws-role-arn"
+	AWSSTSEndpointFlagName        = "aws-sts-endpoint"
+	AWSUnsignedPayloadFlagName    = "aws-unsigned-payload"
+	AWSPresignFlagName            = "aws-presign"
 	BearerFlagName                = "bearer"
+	AuthFlagName                  = "auth"
+	AuthTypeFlagName              = "auth-type"
+	APIKeyFlagName                = "api-key"
+	APIKeyNameFlagName            = "api-key-name"
+	APIKeyInFlagName              = "api-key-in"
+	OAuth2FlagName                = "oauth2"
+	SignFlagName                  = "sign"
 	DataStringFlagName            = "data"
 	DataStdinFlagName             = "data-stdin"
 	DataFileFlagName              = "data-file"
 	DataURLEncodeFlagName         = "data-urlencode"
+	FormFlagName                  = "form"
+	ContentTypeFlagName           = "content-type"
+	JSONFlagName                  = "json"
+	XMLFlagName                   = "xml"
+	ProgressFlagName              = "progress"
+	ExpectContinueFlagName        = "expect-continue"
+	Expect100TimeoutFlagName      = "expect100-timeout"
+	CompressedFlagName            = "compressed"
+	CompressBodyFlagName          = "compress-body"
 	FormatFlagName                = "format"
+	QueryFlagName                 = "query"
+	QueryFileFlagName             = "query-file"
+	VariablesFlagName             = "variables"
+	OperationFlagName             = "operation"
+	IntrospectFlagName            = "introspect"
 	OutfileFlagName               = "outfile"
 	FailFlagName                  = "fail"
 	DetailsFlagName               = "details"
 	TimeoutFlagName               = "timeout"
 	VerboseFlagName               = "verbose"
+	TimingFlagName                = "timing"
 	NoFollowRedirectsFlagName     = "no-follow-redirects"
+	MaxRedirectsFlagName          = "max-redirects"
+	RedirectSameHostOnlyFlagName  = "redirect-same-host-only"
+	RedirectForwardAuthFlagName   = "redirect-forward-auth"
+	Post301FlagName               = "post301"
+	Post302FlagName               = "post302"
+	RetryFlagName                 = "retry"
+	RetryDelayFlagName            = "retry-delay"
+	RetryMaxTimeFlagName          = "retry-max-time"
+	RetryOnFlagName               = "retry-on"
+	SessionFlagName               = "session"
+	SessionHeaderFlagName         = "session-header"
 	AliasHeadingFlagName          = "no-heading"
 	CertfileFlagName              = "cert"
 	CertkeyFlagName               = "key"
@@ -25,4 +65,20 @@ const (
 	TLSMinVersionFlagName         = "tls-min-version"
 	TLSMaxVersionFlagName         = "tls-max-version"
 	TLSInsecureSkipVerifyFlagName = "tls-skip-verify-insecure"
+	CACertFlagName                = "cacert"
+	CAPathFlagName                = "capath"
+	PinFlagName                   = "pin"
+	TLSKeyLogFlagName             = "tls-keylog"
+	SNIFlagName                   = "sni"
+	ALPNFlagName                  = "alpn"
+	HTTP1FlagName                 = "http1.1"
+	HTTP2FlagName                 = "http2"
+	HTTP2PriorKnowledgeFlagName   = "http2-prior-knowledge"
+	ResolveFlagName               = "resolve"
+	ConnectToFlagName             = "connect-to"
+	DNSServerFlagName             = "dns-server"
+	UnixSocketFlagName            = "unix-socket"
+	ProxyFlagName                 = "proxy"
+	NoProxyFlagName               = "no-proxy"
+	ProxyCACertFlagName           = "proxy-cacert"
 )
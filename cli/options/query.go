@@ -0,0 +1,67 @@
+package options
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lunjon/http/internal/client"
+	"github.com/spf13/cobra"
+)
+
+// QueryFromFlags returns the query parameters given by the query
+// flags, in order, where those of the file come first.
+func QueryFromFlags(cmd *cobra.Command) (client.Query, error) {
+	flags := cmd.Flags()
+	query := client.Query{}
+
+	if file, _ := flags.GetString(QueryFileFlagName); file != "" {
+		var err error
+		if query, err = readQueryFile(file, query); err != nil {
+			return nil, err
+		}
+	}
+
+	params, _ := flags.GetStringArray(QueryFlagName)
+	for _, param := range params {
+		var err error
+		if query, err = addQueryParam(query, param); err != nil {
+			return nil, err
+		}
+	}
+	return query, nil
+}
+
+// Reads query parameters from a file with one key=value per line.
+// Empty lines and lines starting with # are ignored.
+func readQueryFile(path string, query client.Query) (client.Query, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if query, err = addQueryParam(query, line); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineno, err)
+		}
+	}
+	return query, scanner.Err()
+}
+
+// Adds a parameter in the format key=value, where the value may be
+// empty. The key and value should not be percent-encoded.
+func addQueryParam(query client.Query, param string) (client.Query, error) {
+	key, value, _ := strings.Cut(param, "=")
+	if key == "" {
+		return nil, fmt.Errorf("invalid query parameter %q: expected key=value", param)
+	}
+	return append(query, client.QueryParam{Key: key, Value: value}), nil
+}
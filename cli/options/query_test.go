@@ -0,0 +1,51 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lunjon/http/internal/client"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func newQueryCommand(t *testing.T, args ...string) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Flags().StringArray(QueryFlagName, []string{}, "")
+	cmd.Flags().String(QueryFileFlagName, "", "")
+	require.NoError(t, cmd.Flags().Parse(args))
+	return cmd
+}
+
+func TestQueryFromFlags(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "query.txt")
+	content := "# comment\nfrom=file\n\nempty=\nkey=a=b\n"
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+
+	cmd := newQueryCommand(t, "--query", "q=a b", "--query", "key=c", "--query", "flag", "--query-file", file)
+	query, err := QueryFromFlags(cmd)
+	require.NoError(t, err)
+	require.Equal(t, client.Query{
+		{Key: "from", Value: "file"},
+		{Key: "empty", Value: ""},
+		{Key: "key", Value: "a=b"},
+		{Key: "q", Value: "a b"},
+		{Key: "key", Value: "c"},
+		{Key: "flag", Value: ""},
+	}, query)
+	require.Equal(t, "from=file&empty=&key=a%3Db&q=a+b&key=c&flag=", query.Encode())
+}
+
+func TestQueryFromFlagsInvalid(t *testing.T) {
+	_, err := QueryFromFlags(newQueryCommand(t, "--query", "=value"))
+	require.Error(t, err)
+
+	file := filepath.Join(t.TempDir(), "query.txt")
+	require.NoError(t, os.WriteFile(file, []byte("a=1\n=2\n"), 0644))
+	_, err = QueryFromFlags(newQueryCommand(t, "--query-file", file))
+	require.ErrorContains(t, err, "query.txt:2")
+
+	_, err = QueryFromFlags(newQueryCommand(t, "--query-file", "does-not-exist"))
+	require.Error(t, err)
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// progressInterval is the minimum time between updates of the progress.
+const progressInterval = 200 * time.Millisecond
+
+// progressReader writes the number of bytes read of the
+// request body to w, on a single line that is updated.
+type progressReader struct {
+	r     io.ReadCloser
+	w     io.Writer
+	total int64
+	read  int64
+	last  time.Time
+	done  bool
+}
+
+// withProgress makes the body of the request, and the body when it
+// is sent again on redirects or retries, write its progress to w.
+func withProgress(req *http.Request, w io.Writer) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	req.Body = &progressReader{r: req.Body, w: w, total: req.ContentLength}
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil || body == http.NoBody {
+				return body, err
+			}
+			return &progressReader{r: body, w: w, total: req.ContentLength}, nil
+		}
+	}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+
+	if err == io.EOF {
+		p.finish()
+	} else if time.Since(p.last) >= progressInterval {
+		p.last = time.Now()
+		p.print()
+	}
+	return n, err
+}
+
+func (p *progressReader) Close() error {
+	p.finish()
+	return p.r.Close()
+}
+
+func (p *progressReader) finish() {
+	if p.done {
+		return
+	}
+	p.done = true
+	p.print()
+	fmt.Fprintln(p.w)
+}
+
+func (p *progressReader) print() {
+	if p.total > 0 {
+		fmt.Fprintf(p.w, "\rUploaded %s of %s (%d%%)", formatSize(p.read), formatSize(p.total), p.read*100/p.total)
+	} else {
+		fmt.Fprintf(p.w, "\rUploaded %s", formatSize(p.read))
+	}
+}
+
+// formatSize returns the size in bytes with a binary unit, e.g. 1.5 MiB.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
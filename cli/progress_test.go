@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatSize(t *testing.T) {
+	require.Equal(t, "512 B", formatSize(512))
+	require.Equal(t, "1.5 KiB", formatSize(1536))
+	require.Equal(t, "2.0 MiB", formatSize(2<<20))
+	require.Equal(t, "5.0 GiB", formatSize(5<<30))
+}
+
+func TestWithProgress(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", strings.NewReader("content"))
+	require.NoError(t, err)
+
+	w := &strings.Builder{}
+	withProgress(req, w)
+	_, err = io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.NoError(t, req.Body.Close())
+	require.True(t, strings.HasSuffix(w.String(), "\rUploaded 7 B of 7 B (100%)\n"), w.String())
+
+	// The body is read again on redirects
+	w.Reset()
+	body, err := req.GetBody()
+	require.NoError(t, err)
+	_, err = io.ReadAll(body)
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(w.String(), "\rUploaded 7 B of 7 B (100%)\n"), w.String())
+}
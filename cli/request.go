@@ -1,7 +1,8 @@
 package cli
 
 import (
-	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -20,12 +21,16 @@ import (
 
 var (
 	newline = []byte("\r\n")
+	// errRequestFailed is returned when the request fails with --fail,
+	// and turned into the exit status once the session is saved.
+	errRequestFailed = errors.New("request failed")
 )
 
 const (
-	userAgentHeader     = "User-Agent"
-	contentTypeHeader   = "Content-Type"
-	contentLengthHeader = "Content-Length"
+	userAgentHeader       = "User-Agent"
+	contentTypeHeader     = "Content-Type"
+	contentLengthHeader   = "Content-Length"
+	contentEncodingHeader = "Content-Encoding"
 )
 
 // RequestHandler handles all commands.
@@ -38,8 +43,18 @@ type RequestHandler struct {
 	historyHandler history.Handler
 	output         io.Writer
 	logger         *log.Logger
-	failFunc       FailFunc
 	outputFile     types.Option[string]
+	timing         timingOptions
+	compressBody   string
+	query          client.Query
+	progress       io.Writer
+}
+
+// timingOptions controls if, and in which format, the timing
+// of each hop of a request is written after the response.
+type timingOptions struct {
+	format types.Option[Format]
+	output io.Writer
 }
 
 func newRequestHandler(
@@ -52,7 +67,10 @@ func newRequestHandler(
 	headers http.Header,
 	output io.Writer,
 	outputFile string,
-	failFunc FailFunc,
+	timing timingOptions,
+	compressBody string,
+	query client.Query,
+	progress io.Writer,
 ) *RequestHandler {
 	outfile := types.Option[string]{}
 	if outputFile != "" {
@@ -68,40 +86,115 @@ func newRequestHandler(
 		historyHandler: historyHandler,
 		output:         output,
 		logger:         logger,
-		failFunc:       failFunc,
 		outputFile:     outfile,
+		timing:         timing,
+		compressBody:   compressBody,
+		query:          query,
+		progress:       progress,
 	}
 }
 
-func (handler *RequestHandler) handleRequest(method, url string, dataOptions options.DataOptions) error {
+// Returns the URL, body and headers of a request.
+func (handler *RequestHandler) prepareRequest(url string, dataOptions options.DataOptions) (*url.URL, client.Body, http.Header, error) {
 	headers, err := handler.getHeaders()
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
-	var body []byte
-	data, mime, err := dataOptions.GetData()
+	body, contentType, err := handler.getBody(dataOptions)
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
-	if data.IsSome() {
-		body = data.MustGet()
-
-		setContentType := headers.Get(contentTypeHeader) == "" && mime != client.MIMETypeUnknown
+	if body != nil {
+		setContentType := headers.Get(contentTypeHeader) == "" && contentType != ""
 		if setContentType {
-			handler.logger.Printf("Detected MIME type: %s", mime)
-			headers.Set(contentTypeHeader, mime.String())
+			handler.logger.Printf("Detected MIME type: %s", contentType)
+			headers.Set(contentTypeHeader, contentType)
 		}
 
-		setContentLength := headers.Get(contentLengthHeader) == "" && len(body) > 0
+		setContentLength := headers.Get(contentLengthHeader) == "" && body.Len() > 0
 		if setContentLength {
 			handler.logger.Printf("Adding %s header", contentLengthHeader)
-			headers.Set(contentLengthHeader, fmt.Sprint(len(body)))
+			headers.Set(contentLengthHeader, fmt.Sprint(body.Len()))
 		}
 	}
 
 	u, err := client.ParseURL(url, handler.cfg.Aliases)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	client.AddQuery(u, handler.query)
+	return u, body, headers, nil
+}
+
+// Returns the request body given by the options, if any, and its MIME type.
+func (handler *RequestHandler) getBody(dataOptions options.DataOptions) (client.Body, string, error) {
+	form, err := dataOptions.GetForm()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if form != nil {
+		if handler.compressBody != "" {
+			return nil, "", fmt.Errorf("a multipart form cannot be compressed")
+		}
+		return form, form.ContentType(), nil
+	}
+
+	if handler.compressBody == "" {
+		body, mime, err := dataOptions.GetBody()
+		if err != nil || body == nil {
+			return nil, "", err
+		}
+
+		// Signers need to read the body before it is sent
+		if stream, ok := body.(*client.StreamBody); ok && !isDefaultSigner(handler.signer) {
+			handler.logger.Print("Reading request body into memory for signing")
+			body, err = stream.ReadAll()
+			if err != nil {
+				return nil, "", err
+			}
+		}
+		return body, contentTypeOf(mime), nil
+	}
+
+	// Compressed bodies are kept in memory
+	data, mime, err := dataOptions.GetData()
+	if err != nil || !data.IsSome() {
+		return nil, "", err
+	}
+
+	body := data.MustGet()
+	if len(body) > 0 {
+		compressed, err := client.Compress(handler.compressBody, body)
+		if err != nil {
+			return nil, "", err
+		}
+
+		handler.logger.Printf("Compressed request body with %s: %d -> %d bytes", handler.compressBody, len(body), len(compressed))
+		handler.headers.Set(contentEncodingHeader, handler.compressBody)
+		body = compressed
+	}
+
+	return client.BytesBody(body), contentTypeOf(mime), nil
+}
+
+func contentTypeOf(mime client.MIMEType) string {
+	if mime == client.MIMETypeUnknown {
+		return ""
+	}
+	return mime.String()
+}
+
+func isDefaultSigner(signer client.RequestSigner) bool {
+	_, ok := signer.(client.DefaultSigner)
+	return ok
+}
+
+func (handler *RequestHandler) handleRequest(method, url string, dataOptions options.DataOptions) error {
+	u, body, headers, err := handler.prepareRequest(url, dataOptions)
 	if err != nil {
 		return err
 	}
@@ -111,12 +204,19 @@ func (handler *RequestHandler) handleRequest(method, url string, dataOptions opt
 		return err
 	}
 
-	// Add to history
+	if handler.progress != nil {
+		withProgress(req, handler.progress)
+	}
+
+	// Add to history, which is waited for also if the request fails
 	wg := sync.WaitGroup{}
+	defer wg.Wait()
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		_, err := handler.historyHandler.Append(req, body)
+		// Only bodies in memory are kept in the history
+		b, _ := body.(client.BytesBody)
+		_, err := handler.historyHandler.Append(req, b)
 		if err != nil {
 			handler.logger.Printf("Error building history entry: %s", err)
 			return
@@ -133,31 +233,101 @@ func (handler *RequestHandler) handleRequest(method, url string, dataOptions opt
 		return err
 	}
 
-	err = handler.outputResults(res)
+	res, err = handler.respondToChallenge(res, method, u, body, headers)
 	if err != nil {
 		return err
 	}
 
-	// Wait for history to be written
-	wg.Wait()
-	return nil
+	return handler.outputResults(res)
 }
 
 func (handler *RequestHandler) buildRequest(
 	method string,
 	url *url.URL,
-	body []byte,
+	body client.Body,
 	header http.Header,
 ) (*http.Request, error) {
-	req, err := handler.client.BuildRequest(method, url, body, header)
+	req, err := handler.client.BuildBodyRequest(method, url, body, header)
 	if err != nil {
 		return nil, err
 	}
 
-	err = handler.signer.Sign(req, bytes.NewReader(body))
+	// Streams are only sent with the default signer, which
+	// does not read the body, since they can be read once.
+	if body == nil || body.Len() < 0 {
+		body = client.BytesBody(nil)
+	}
+	payload, err := body.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	seeker, ok := payload.(io.ReadSeeker)
+	if !ok {
+		payload.Close()
+		return nil, fmt.Errorf("request body cannot be signed since it is not seekable")
+	}
+
+	// Some signers, e.g. AWS signature V4, replace the
+	// body of the request with the payload they signed.
+	original := req.Body
+	err = handler.signer.Sign(req, seeker)
+	if req.Body == payload {
+		if original != nil {
+			original.Close()
+		}
+	} else {
+		payload.Close()
+	}
 	return req, err
 }
 
+// handlePresign outputs the URL of the signed request, without sending it.
+func (handler *RequestHandler) handlePresign(method, url string, dataOptions options.DataOptions) error {
+	u, body, headers, err := handler.prepareRequest(url, dataOptions)
+	if err != nil {
+		return err
+	}
+
+	req, err := handler.buildRequest(method, u, body, headers)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(handler.output, req.URL.String())
+	return err
+}
+
+// Sends the request again, if the signer responds
+// to an authentication challenge in the response.
+func (handler *RequestHandler) respondToChallenge(
+	res *http.Response,
+	method string,
+	url *url.URL,
+	body client.Body,
+	header http.Header,
+) (*http.Response, error) {
+	signer, ok := handler.signer.(client.ChallengeSigner)
+	if !ok || res.StatusCode != http.StatusUnauthorized {
+		return res, nil
+	}
+
+	retry, err := signer.Challenge(res)
+	if err != nil || !retry {
+		return res, err
+	}
+
+	handler.logger.Print("Responding to authentication challenge")
+	_, _ = io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	req, err := handler.buildRequest(method, url, body, header)
+	if err != nil {
+		return nil, err
+	}
+	return handler.client.Send(req)
+}
+
 func (handler *RequestHandler) outputResults(r *http.Response) error {
 	b, err := handler.formatter.FormatResponse(r)
 	if err != nil {
@@ -178,15 +348,45 @@ func (handler *RequestHandler) outputResults(r *http.Response) error {
 		}
 	}
 
-	doFail := handler.cfg.Fail && r.StatusCode >= 400
-	if doFail {
+	err = handler.outputTiming()
+	if err != nil {
+		return err
+	}
+
+	if !handler.cfg.Fail {
+		return nil
+	}
+
+	if r.StatusCode >= 400 {
 		handler.logger.Printf("Request failed with status %s", r.Status)
-		handler.failFunc(1)
+		return errRequestFailed
+	} else if f, ok := handler.formatter.(failingFormatter); ok && f.Failed() {
+		handler.logger.Print("Request failed with errors in the response")
+		return errRequestFailed
 	}
 
 	return nil
 }
 
+// outputTiming writes the timing of each hop, if enabled.
+// It must be called after the response body has been read.
+func (handler *RequestHandler) outputTiming() error {
+	format, ok := handler.timing.format.Get()
+	if !ok {
+		return nil
+	}
+
+	timings := handler.client.Timings()
+	switch format {
+	case TextFormat:
+		_, err := fmt.Fprint(handler.timing.output, client.FormatTimings(timings))
+		return err
+	case JSONFormat:
+		return json.NewEncoder(handler.timing.output).Encode(timings)
+	}
+	return fmt.Errorf("unknown timing format: %s", format)
+}
+
 // Get request headers passed as parameters and defaultHeaders.
 // Also sets the User-Agent header if not set by the client.
 func (handler *RequestHandler) getHeaders() (http.Header, error) {
@@ -14,15 +14,10 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-type testState struct {
-	failCalled bool
-}
-
 type fixture struct {
 	handler     *RequestHandler
 	infos       *strings.Builder
 	errors      *strings.Builder
-	state       *testState
 	historyMock history.Handler
 }
 
@@ -37,11 +32,6 @@ func setupRequestTest(_ *testing.T, cfgs ...config.Config) *fixture {
 
 	c, _ := client.NewClient(settings, logger, logger)
 
-	state := &testState{}
-	failFunc := func(int) {
-		state.failCalled = true
-	}
-
 	historyHandler := history.NewHandler(testHistoryPath)
 	formatter := &formatterMock{}
 	signer := &signerMock{}
@@ -61,14 +51,16 @@ func setupRequestTest(_ *testing.T, cfgs ...config.Config) *fixture {
 		http.Header{},
 		infos,
 		"",
-		failFunc,
+		timingOptions{},
+		"",
+		nil,
+		nil,
 	)
 
 	return &fixture{
 		handler:     handler,
 		infos:       infos,
 		errors:      errors,
-		state:       state,
 		historyMock: historyHandler,
 	}
 }
@@ -90,8 +82,7 @@ func TestGetErrorWithFail(t *testing.T) {
 	fixture := setupRequestTest(t, config.New().UseFail(true))
 
 	err := fixture.handler.handleRequest("get", testServer.URL+"/error", options.DataOptions{})
-	require.NoError(t, err)
-	require.True(t, fixture.state.failCalled)
+	require.ErrorIs(t, err, errRequestFailed)
 	require.Empty(t, fixture.infos.String())
 	require.Empty(t, fixture.errors.String())
 }
@@ -111,3 +102,12 @@ func TestPost(t *testing.T) {
 		require.Empty(t, fixture.errors.String())
 	}
 }
+
+func TestGetWithDigestAuth(t *testing.T) {
+	fixture := setupRequestTest(t)
+	fixture.handler.signer = client.NewDigestSigner("user", "pass")
+
+	err := fixture.handler.handleRequest(http.MethodGet, testServer.URL+"/digest", options.DataOptions{})
+	require.NoError(t, err)
+	require.Contains(t, fixture.infos.String(), "authorized")
+}
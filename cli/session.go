@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lunjon/http/internal/session"
+	"github.com/lunjon/http/internal/style"
+	"github.com/lunjon/http/internal/types"
+	"github.com/spf13/cobra"
+)
+
+func buildSession(cfg cliConfig) *cobra.Command {
+	handler := session.NewHandler(cfg.sessionsDir)
+
+	list := func(cmd *cobra.Command, _ []string) {
+		names, err := handler.List()
+		checkErr(err, cfg.errors)
+
+		for _, name := range names {
+			fmt.Fprintln(cfg.infos, name)
+		}
+	}
+
+	root := &cobra.Command{
+		Use:   "session",
+		Short: "Command for managing sessions",
+		Long: `Command for managing sessions.
+
+Sessions are used with the --session NAME option of the HTTP commands.
+A session stores the cookies set by servers and the headers given
+with the command, so that they are sent in later requests.`,
+		Run: list,
+	}
+
+	root.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List sessions",
+			Args:  cobra.NoArgs,
+			Run:   list,
+		},
+		&cobra.Command{
+			Use:   "show <name>",
+			Short: "Show headers and cookies of a session",
+			Args:  cobra.ExactArgs(1),
+			Run: func(cmd *cobra.Command, args []string) {
+				s, err := handler.Get(args[0])
+				checkErr(err, cfg.errors)
+
+				err = writeSession(cfg.infos, s)
+				checkErr(err, cfg.errors)
+			},
+		},
+		&cobra.Command{
+			Use:   "clear <name>",
+			Short: "Removes a session",
+			Args:  cobra.ExactArgs(1),
+			Run: func(cmd *cobra.Command, args []string) {
+				err := handler.Clear(args[0])
+				checkErr(err, cfg.errors)
+			},
+		},
+	)
+	return root
+}
+
+func writeSession(w io.Writer, s *session.Session) error {
+	names := []string{}
+	for name := range s.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	taber := types.NewTaber("  ")
+	taber.Writef("%s\n", style.GreenB.Render("Headers"))
+	for _, name := range names {
+		taber.WriteLine(style.Bold.Render(name+":"), strings.Join(s.Header[name], "; "))
+	}
+	if _, err := fmt.Fprint(w, taber.String()); err != nil {
+		return err
+	}
+
+	taber = types.NewTaber("  ")
+	taber.Writef("\n%s\n", style.GreenB.Render("Cookies"))
+	for _, c := range s.Cookies {
+		expires := "session"
+		if !c.Expires.IsZero() {
+			expires = c.Expires.Local().Format(time.RFC1123)
+		}
+		taber.WriteLine(style.Bold.Render(c.Name), c.Value, c.URL, expires)
+	}
+	_, err := fmt.Fprint(w, taber.String())
+	return err
+}
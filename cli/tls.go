@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lunjon/http/cli/options"
+	"github.com/lunjon/http/internal/client"
+	"github.com/lunjon/http/internal/style"
+	"github.com/lunjon/http/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// Certificates expiring within this many days are highlighted.
+const expiryWarningDays = 30
+
+func buildTLS(cfg cliConfig) *cobra.Command {
+	connOpts := newConnectionOptions()
+	noProbeFlagName := "no-probe"
+
+	cmd := &cobra.Command{
+		Use:   "tls <host[:port]>",
+		Short: "Inspect the TLS configuration of a server",
+		Long: `Inspect the TLS configuration of a server.
+
+Performs a TLS handshake, without sending any request, and reports the negotiated
+version, cipher suite and ALPN protocol, the stapled OCSP response and the
+certificate chain sent by the server, along with any error verifying it.
+
+The host is parsed in the same way as the URL of the HTTP commands,
+but the port defaults to 443.
+
+Unless --no-probe is given, the server is also probed for the TLS versions and
+cipher suites that it accepts. Only cipher suites supported by Go are probed,
+and for TLS 1.3 only the negotiated cipher suite is shown.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			flags := cmd.Flags()
+			appConfig, err := cfg.getAppConfig()
+			checkErr(err, cfg.errors)
+			appConfig = updateConfig(cmd, appConfig)
+
+			settings, err := buildSettings(cmd, appConfig, connOpts, args[0])
+			checkErr(err, cfg.errors)
+
+			u, err := client.ParseURL(args[0], appConfig.Aliases)
+			checkErr(err, cfg.errors)
+			port := u.Port()
+			if port == "" {
+				port = "443"
+			}
+
+			noProbe, _ := flags.GetBool(noProbeFlagName)
+			report, err := settings.InspectTLS(context.Background(), u.Hostname(), port, !noProbe)
+			checkErr(err, cfg.errors)
+
+			format, _ := flags.GetString(options.FormatFlagName)
+			switch Format(format) {
+			case TextFormat:
+				err = writeTLSReport(cfg.infos, report)
+			case JSONFormat:
+				enc := json.NewEncoder(cfg.infos)
+				enc.SetIndent("", "  ")
+				err = enc.Encode(report)
+			default:
+				err = fmt.Errorf("unknown format: %s", format)
+			}
+			checkErr(err, cfg.errors)
+		},
+	}
+
+	addConnectionFlags(cmd, connOpts)
+	flags := cmd.Flags()
+	flags.String(options.FormatFlagName, "text", `Output format of the report. Possible values: text, json.`)
+	flags.DurationP(options.TimeoutFlagName, "T", defaultTimeout, "Timeout of each handshake.")
+	flags.Bool(noProbeFlagName, false, "Do not probe for the accepted TLS versions and cipher suites.")
+	return cmd
+}
+
+func writeTLSReport(w io.Writer, report *client.TLSReport) error {
+	taber := types.NewTaber("  ")
+	taber.Writef("%s\n", style.GreenB.Render("Connection"))
+	taber.WriteLine(style.Bold.Render("Address:"), report.Address)
+	taber.WriteLine(style.Bold.Render("Server name:"), report.ServerName)
+	taber.WriteLine(style.Bold.Render("Version:"), report.Version)
+	taber.WriteLine(style.Bold.Render("Cipher suite:"), report.CipherSuite)
+	taber.WriteLine(style.Bold.Render("ALPN:"), valueOrNone(report.ALPN))
+
+	ocsp := "not stapled"
+	if report.OCSP != nil {
+		ocsp = report.OCSP.Status
+		if report.OCSP.Error != "" {
+			ocsp += ": " + report.OCSP.Error
+		} else {
+			ocsp += fmt.Sprintf(" (next update %s)", report.OCSP.NextUpdate.Format("2006-01-02"))
+		}
+	}
+	taber.WriteLine(style.Bold.Render("OCSP:"), ocsp)
+
+	verification := style.Green.Render("ok")
+	if report.VerificationError != "" {
+		verification = style.RedB.Render(report.VerificationError)
+	}
+	taber.WriteLine(style.Bold.Render("Verification:"), verification)
+	if _, err := fmt.Fprint(w, taber.String()); err != nil {
+		return err
+	}
+
+	for i, cert := range report.Chain {
+		taber = types.NewTaber("  ")
+		taber.Writef("\n%s\n", style.GreenB.Render(fmt.Sprintf("Certificate %d", i)))
+		taber.WriteLine(style.Bold.Render("Subject:"), cert.Subject)
+		taber.WriteLine(style.Bold.Render("Issuer:"), cert.Issuer)
+		if len(cert.DNSNames) > 0 || len(cert.IPAddresses) > 0 {
+			taber.WriteLine(style.Bold.Render("SANs:"), strings.Join(append(cert.DNSNames, cert.IPAddresses...), ", "))
+		}
+		taber.WriteLine(style.Bold.Render("Key:"), cert.KeyType)
+		taber.WriteLine(style.Bold.Render("Signature:"), cert.SignatureAlgorithm)
+		taber.WriteLine(style.Bold.Render("Serial:"), cert.SerialNumber)
+		taber.WriteLine(style.Bold.Render("Valid:"), fmt.Sprintf("%s to %s",
+			cert.NotBefore.Format("2006-01-02"),
+			cert.NotAfter.Format("2006-01-02")))
+		taber.WriteLine(style.Bold.Render("Expires in:"), expiryString(cert.DaysToExpiry))
+		taber.WriteLine(style.Bold.Render("SHA-256:"), cert.SHA256Fingerprint)
+		taber.WriteLine(style.Bold.Render("Pin:"), cert.PublicKeyPin)
+		if _, err := fmt.Fprint(w, taber.String()); err != nil {
+			return err
+		}
+	}
+
+	if len(report.Accepted) == 0 {
+		return nil
+	}
+
+	taber = types.NewTaber("  ")
+	taber.Writef("\n%s\n", style.GreenB.Render("Accepted"))
+	for _, v := range report.Accepted {
+		suites := valueOrNone(strings.Join(v.CipherSuites, "\n\t"))
+		taber.WriteLine(style.Bold.Render(v.Version+":"), suites)
+	}
+	_, err := fmt.Fprint(w, taber.String())
+	return err
+}
+
+func expiryString(days int) string {
+	switch {
+	case days < 0:
+		return style.RedB.Render(fmt.Sprintf("expired %d days ago", -days))
+	case days < expiryWarningDays:
+		return style.YellowB.Render(fmt.Sprintf("%d days", days))
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+func valueOrNone(s string) string {
+	if s == "" {
+		return style.Grey.Render("none")
+	}
+	return s
+}
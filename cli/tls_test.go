@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lunjon/http/internal/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSInspect(t *testing.T) {
+	srv := httptest.NewUnstartedServer(testServer.Config.Handler)
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+	addr := srv.Listener.Addr().String()
+
+	fixture := setupCommandTest("tls", addr, "--format", "json")
+	err := fixture.cmd.Execute()
+	require.NoError(t, err)
+
+	var report client.TLSReport
+	require.NoError(t, json.Unmarshal([]byte(fixture.infos.String()), &report))
+	require.Equal(t, addr, report.Address)
+	require.Equal(t, "TLS 1.3", report.Version)
+	require.Len(t, report.Chain, 1)
+	require.Contains(t, report.Chain[0].DNSNames, "example.com")
+	require.Equal(t, "RSA 2048", report.Chain[0].KeyType)
+	require.Contains(t, report.VerificationError, "unknown authority")
+	require.NotEmpty(t, report.Accepted)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, caPEM, 0600))
+
+	fixture = setupCommandTest("tls", addr, "--cacert", caFile, "--no-probe")
+	err = fixture.cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, fixture.infos.String(), "Certificate 0")
+	require.Contains(t, fixture.infos.String(), client.PublicKeyPin(srv.Certificate()))
+	require.NotContains(t, fixture.infos.String(), "Accepted")
+	require.NotContains(t, fixture.infos.String(), "unknown authority")
+}
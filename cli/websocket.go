@@ -0,0 +1,333 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/lunjon/http/cli/options"
+	"github.com/lunjon/http/internal/client"
+	"github.com/spf13/cobra"
+)
+
+const closeWait = time.Second * 2
+
+// WebSocketHandler handles the messages of an established
+// WebSocket connection.
+type WebSocketHandler struct {
+	conn     *websocket.Conn
+	output   io.Writer
+	logger   *log.Logger
+	messages chan []byte
+	// Set when the read loop exits, before messages is closed.
+	readErr error
+	// Closed by stop, so that the read loop exits
+	// also if no one receives the messages.
+	done chan struct{}
+	// Closed when the read loop has exited.
+	readDone chan struct{}
+	stopOnce sync.Once
+}
+
+func newWebSocketHandler(conn *websocket.Conn, output io.Writer, logger *log.Logger) *WebSocketHandler {
+	handler := &WebSocketHandler{
+		conn:     conn,
+		output:   output,
+		logger:   logger,
+		messages: make(chan []byte),
+		done:     make(chan struct{}),
+		readDone: make(chan struct{}),
+	}
+
+	conn.SetPingHandler(func(data string) error {
+		logger.Printf("Received ping: %q", data)
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		if errors.Is(err, websocket.ErrCloseSent) {
+			return nil
+		}
+		return err
+	})
+	conn.SetPongHandler(func(data string) error {
+		logger.Printf("Received pong: %q", data)
+		return nil
+	})
+
+	go handler.readLoop()
+	return handler
+}
+
+func (handler *WebSocketHandler) readLoop() {
+	defer close(handler.readDone)
+	defer close(handler.messages)
+	for {
+		kind, data, err := handler.conn.ReadMessage()
+		if err != nil {
+			handler.readErr = err
+			return
+		}
+
+		if kind == websocket.BinaryMessage {
+			handler.logger.Printf("Received binary message (%d bytes)", len(data))
+		}
+		select {
+		case handler.messages <- data:
+		case <-handler.done:
+			return
+		}
+	}
+}
+
+// stop closes the connection and waits for the read loop to exit.
+func (handler *WebSocketHandler) stop() {
+	handler.stopOnce.Do(func() {
+		close(handler.done)
+		handler.conn.Close()
+	})
+	<-handler.readDone
+}
+
+// Returns nil if the connection was closed normally.
+func (handler *WebSocketHandler) closeErr() error {
+	var closeErr *websocket.CloseError
+	if errors.As(handler.readErr, &closeErr) {
+		handler.logger.Printf("Connection closed by server: %d %s", closeErr.Code, closeErr.Text)
+		if closeErr.Code == websocket.CloseNormalClosure || closeErr.Code == websocket.CloseGoingAway {
+			return nil
+		}
+	}
+	return handler.readErr
+}
+
+func (handler *WebSocketHandler) print(data []byte) error {
+	_, err := handler.output.Write(data)
+	if err == nil {
+		_, err = handler.output.Write([]byte("\n"))
+	}
+	return err
+}
+
+func (handler *WebSocketHandler) send(msg string) error {
+	handler.logger.Printf("Sending message (%d bytes)", len(msg))
+	return handler.conn.WriteMessage(websocket.TextMessage, []byte(msg))
+}
+
+func (handler *WebSocketHandler) ping(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			handler.logger.Print("Sending ping")
+			err := handler.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second))
+			if err != nil {
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// close performs the closing handshake and closes the connection.
+func (handler *WebSocketHandler) close() error {
+	defer handler.stop()
+
+	handler.logger.Print("Closing connection")
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	err := handler.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(closeWait))
+	if err != nil && !errors.Is(err, websocket.ErrCloseSent) {
+		return err
+	}
+
+	// Wait for the server to respond with a close frame,
+	// outputting any message received in the meantime.
+	timeout := time.After(closeWait)
+	for {
+		select {
+		case data, ok := <-handler.messages:
+			if !ok {
+				return handler.closeErr()
+			}
+			if err := handler.print(data); err != nil {
+				return err
+			}
+		case <-timeout:
+			handler.logger.Print("Timed out waiting for close from server")
+			return nil
+		}
+	}
+}
+
+// interactive sends each line read from input as a text message
+// and outputs every message received until either side closes.
+func (handler *WebSocketHandler) interactive(input io.Reader, interrupt <-chan os.Signal) error {
+	defer handler.stop()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(input)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return handler.close()
+			}
+			if err := handler.send(line); err != nil {
+				return err
+			}
+		case data, ok := <-handler.messages:
+			if !ok {
+				return handler.closeErr()
+			}
+			if err := handler.print(data); err != nil {
+				return err
+			}
+		case <-interrupt:
+			return handler.close()
+		}
+	}
+}
+
+// script sends all messages and then waits for a message matching each
+// of the expectations, in order, before closing the connection.
+func (handler *WebSocketHandler) script(sends []string, expects []*regexp.Regexp, timeout time.Duration) error {
+	defer handler.stop()
+
+	deadline := time.After(timeout)
+	for _, msg := range sends {
+		if err := handler.send(msg); err != nil {
+			return err
+		}
+	}
+
+	for _, expect := range expects {
+		matched := false
+		for !matched {
+			select {
+			case data, ok := <-handler.messages:
+				if !ok {
+					if err := handler.closeErr(); err != nil {
+						return err
+					}
+					return fmt.Errorf("connection closed before receiving message matching: %s", expect)
+				}
+				if err := handler.print(data); err != nil {
+					return err
+				}
+				matched = expect.Match(data)
+			case <-deadline:
+				handler.close()
+				return fmt.Errorf("timed out waiting for message matching: %s", expect)
+			}
+		}
+	}
+
+	return handler.close()
+}
+
+func buildWebSocket(cfg cliConfig) *cobra.Command {
+	connOpts := newConnectionOptions()
+	sendFlagName := "send"
+	expectFlagName := "expect"
+	pingIntervalFlagName := "ping-interval"
+
+	cmd := &cobra.Command{
+		Use:     "ws <url>",
+		Aliases: []string{"websocket"},
+		Short:   "Open a WebSocket connection",
+		Long: `Open a WebSocket connection.
+
+The URL is parsed in the same way as for the HTTP commands, but the scheme
+is changed to ws or wss, e.g. :1234/ws becomes ws://localhost:1234/ws.
+
+By default an interactive session is started: each line read from stdin is sent
+as a text message and each message received is written to stdout.
+The connection is closed when stdin is closed (e.g. CTRL-D) or on CTRL-C.
+
+If --send or --expect is given, all messages are sent and then the command waits
+until a message matching each --expect pattern has been received, in order,
+after which the connection is closed. The command fails if this takes longer
+than --timeout.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			flags := cmd.Flags()
+			appConfig, err := cfg.getAppConfig()
+			checkErr(err, cfg.errors)
+
+			appConfig = updateConfig(cmd, appConfig)
+			logger, traceLogger := buildLoggers(cmd, cfg, appConfig)
+
+			settings, err := buildSettings(cmd, appConfig, connOpts, args[0])
+			checkErr(err, cfg.errors)
+			warnInsecure(cfg, settings)
+
+			url, socket, err := client.SplitUnixSocketURL(args[0], appConfig.Aliases)
+			checkErr(err, cfg.errors)
+			if socket != "" {
+				settings = settings.WithUnixSocket(socket)
+			}
+
+			cl, err := client.NewClient(settings, logger, traceLogger)
+			checkErr(err, cfg.errors)
+			defer cl.Close()
+
+			u, err := client.ParseWebSocketURL(url, appConfig.Aliases)
+			checkErr(err, cfg.errors)
+
+			sends, _ := flags.GetStringArray(sendFlagName)
+			patterns, _ := flags.GetStringArray(expectFlagName)
+			expects := []*regexp.Regexp{}
+			for _, pattern := range patterns {
+				re, err := regexp.Compile(pattern)
+				checkErr(err, cfg.errors)
+				expects = append(expects, re)
+			}
+
+			conn, _, err := cl.DialWebSocket(context.Background(), u, buildHeader(cmd, connOpts))
+			checkErr(err, cfg.errors)
+
+			handler := newWebSocketHandler(conn, cfg.infos, logger)
+
+			pingInterval, _ := flags.GetDuration(pingIntervalFlagName)
+			if pingInterval > 0 {
+				stop := make(chan struct{})
+				defer close(stop)
+				go handler.ping(pingInterval, stop)
+			}
+
+			if len(sends) > 0 || len(expects) > 0 {
+				err = handler.script(sends, expects, appConfig.Timeout)
+			} else {
+				sig := make(chan os.Signal, 1)
+				signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+				err = handler.interactive(os.Stdin, sig)
+			}
+			checkErr(err, cfg.errors)
+		},
+	}
+
+	addConnectionFlags(cmd, connOpts)
+	flags := cmd.Flags()
+	flags.StringArray(sendFlagName, []string{}, "Send message. May be specified multiple times.")
+	flags.StringArray(expectFlagName, []string{}, `Wait for a message matching the regular expression.
+May be specified multiple times.`)
+	flags.DurationP(options.TimeoutFlagName, "T", defaultTimeout, "Handshake timeout, and time limit when using --send or --expect.")
+	flags.Duration(pingIntervalFlagName, 0, "Send a ping with this interval. Disabled by default.")
+	return cmd
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/lunjon/http/internal/client"
+	"github.com/lunjon/http/internal/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func setupWebSocketTest(t *testing.T) (*WebSocketHandler, *strings.Builder) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			kind, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(kind, append([]byte("echo: "), data...))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	logger := logging.NewSilentLogger()
+	cl, err := client.NewClient(client.NewSettings(), logger, logger)
+	require.NoError(t, err)
+
+	u, err := client.ParseWebSocketURL(server.URL, nil)
+	require.NoError(t, err)
+
+	conn, _, err := cl.DialWebSocket(t.Context(), u, http.Header{})
+	require.NoError(t, err)
+
+	output := &strings.Builder{}
+	return newWebSocketHandler(conn, output, logger), output
+}
+
+func TestWebSocketScript(t *testing.T) {
+	handler, output := setupWebSocketTest(t)
+
+	expects := []*regexp.Regexp{regexp.MustCompile("^echo: one$"), regexp.MustCompile("two")}
+	err := handler.script([]string{"one", "two"}, expects, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "echo: one\necho: two\n", output.String())
+}
+
+func TestWebSocketScriptTimeout(t *testing.T) {
+	handler, _ := setupWebSocketTest(t)
+
+	expects := []*regexp.Regexp{regexp.MustCompile("never")}
+	err := handler.script([]string{"one"}, expects, time.Millisecond*100)
+	require.Error(t, err)
+}
+
+func TestWebSocketInteractive(t *testing.T) {
+	handler, output := setupWebSocketTest(t)
+
+	err := handler.interactive(strings.NewReader("hello\n"), nil)
+	require.NoError(t, err)
+	require.Equal(t, "echo: hello\n", output.String())
+}
+
+func TestWebSocketStop(t *testing.T) {
+	handler, _ := setupWebSocketTest(t)
+	require.NoError(t, handler.send("unread"))
+
+	// The read loop must exit, although the echo is never received
+	stopped := make(chan struct{})
+	go func() {
+		handler.stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second * 5):
+		require.FailNow(t, "read loop did not exit")
+	}
+
+	_, ok := <-handler.messages
+	require.False(t, ok)
+}
@@ -4,11 +4,16 @@ go 1.24
 
 require (
 	github.com/BurntSushi/toml v1.4.0
+	github.com/andybalholm/brotli v1.1.1
 	github.com/aws/aws-sdk-go v1.44.254
 	github.com/charmbracelet/lipgloss v1.0.0
+	github.com/gorilla/websocket v1.5.3
+	github.com/klauspost/compress v1.18.0
 	github.com/spf13/cobra v1.8.1
 	github.com/stretchr/testify v1.10.0
 	golang.org/x/crypto v0.31.0
+	golang.org/x/term v0.27.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -25,5 +30,4 @@ require (
 	github.com/rivo/uniseg v0.4.7 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/sys v0.28.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
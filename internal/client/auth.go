@@ -0,0 +1,355 @@
+package client
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// ChallengeSigner is a RequestSigner that can respond to an
+// authentication challenge, i.e. a 401 Unauthorized response.
+type ChallengeSigner interface {
+	RequestSigner
+	// Challenge is called with a response with status 401.
+	// It returns true if the request should be signed and sent again.
+	Challenge(res *http.Response) (bool, error)
+}
+
+// BasicSigner uses basic authentication.
+type BasicSigner struct {
+	username string
+	password string
+}
+
+func NewBasicSigner(username, password string) *BasicSigner {
+	return &BasicSigner{
+		username: username,
+		password: password,
+	}
+}
+
+func (s *BasicSigner) Sign(r *http.Request, body io.ReadSeeker) error {
+	r.SetBasicAuth(s.username, s.password)
+	return nil
+}
+
+// BearerSigner sets a bearer token in the Authorization header.
+type BearerSigner struct {
+	token string
+}
+
+func NewBearerSigner(token string) *BearerSigner {
+	return &BearerSigner{token: token}
+}
+
+func (s *BearerSigner) Sign(r *http.Request, body io.ReadSeeker) error {
+	r.Header.Set("Authorization", "Bearer "+s.token)
+	return nil
+}
+
+type APIKeyLocation string
+
+const (
+	APIKeyInHeader APIKeyLocation = "header"
+	APIKeyInQuery  APIKeyLocation = "query"
+)
+
+// APIKeySigner sets an API key in a named header or query parameter.
+type APIKeySigner struct {
+	key  string
+	name string
+	in   APIKeyLocation
+}
+
+func NewAPIKeySigner(key, name string, in APIKeyLocation) (*APIKeySigner, error) {
+	if name == "" {
+		return nil, fmt.Errorf("missing name of API key")
+	}
+	if in != APIKeyInHeader && in != APIKeyInQuery {
+		return nil, fmt.Errorf("invalid API key location: %s", in)
+	}
+
+	return &APIKeySigner{
+		key:  key,
+		name: name,
+		in:   in,
+	}, nil
+}
+
+func (s *APIKeySigner) Sign(r *http.Request, body io.ReadSeeker) error {
+	if s.in == APIKeyInHeader {
+		r.Header.Set(s.name, s.key)
+		return nil
+	}
+
+	// The query is kept as is, since re-encoding it could change the
+	// order and encoding of the parameters, e.g. of a signed URL.
+	param := url.QueryEscape(s.name) + "=" + url.QueryEscape(s.key)
+	if r.URL.RawQuery == "" {
+		r.URL.RawQuery = param
+	} else {
+		r.URL.RawQuery += "&" + param
+	}
+	return nil
+}
+
+// DigestSigner uses digest access authentication (RFC 7616).
+// Requests are sent without authorization until the server
+// responds with a challenge.
+type DigestSigner struct {
+	username  string
+	password  string
+	challenge map[string]string
+	count     int
+}
+
+func NewDigestSigner(username, password string) *DigestSigner {
+	return &DigestSigner{
+		username: username,
+		password: password,
+	}
+}
+
+func (s *DigestSigner) Challenge(res *http.Response) (bool, error) {
+	// A second challenge means the credentials were rejected,
+	// unless the server says that the nonce was stale.
+	answered := s.challenge != nil
+
+	for _, value := range res.Header.Values("WWW-Authenticate") {
+		for _, c := range parseChallenges(value) {
+			if !strings.EqualFold(c.scheme, "digest") {
+				continue
+			}
+
+			if answered && !strings.EqualFold(c.params["stale"], "true") {
+				return false, nil
+			}
+
+			if _, err := digestHash(c.params["algorithm"]); err != nil {
+				return false, err
+			}
+			s.challenge = c.params
+			s.count = 0
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *DigestSigner) Sign(r *http.Request, body io.ReadSeeker) error {
+	if s.challenge == nil {
+		return nil
+	}
+
+	algorithm := s.challenge["algorithm"]
+	newHash, err := digestHash(algorithm)
+	if err != nil {
+		return err
+	}
+	h := func(values ...string) string {
+		hash := newHash()
+		hash.Write([]byte(strings.Join(values, ":")))
+		return hex.EncodeToString(hash.Sum(nil))
+	}
+
+	realm := s.challenge["realm"]
+	nonce := s.challenge["nonce"]
+	uri := r.URL.RequestURI()
+	s.count++
+	nc := fmt.Sprintf("%08x", s.count)
+	cnonce := rand.Text()
+
+	ha1 := h(s.username, realm, s.password)
+	if strings.HasSuffix(strings.ToLower(algorithm), "-sess") {
+		ha1 = h(ha1, nonce, cnonce)
+	}
+
+	qop := selectQOP(s.challenge["qop"])
+	ha2 := h(r.Method, uri)
+	if qop == "auth-int" {
+		b := []byte{}
+		if body != nil {
+			if b, err = io.ReadAll(body); err != nil {
+				return err
+			}
+			if _, err = body.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		hash := newHash()
+		hash.Write(b)
+		ha2 = h(r.Method, uri, hex.EncodeToString(hash.Sum(nil)))
+	}
+
+	params := []string{
+		"username=" + quoteString(s.username),
+		"realm=" + quoteString(realm),
+		"nonce=" + quoteString(nonce),
+		"uri=" + quoteString(uri),
+	}
+	if qop == "" {
+		params = append(params, "response="+quoteString(h(ha1, nonce, ha2)))
+	} else {
+		params = append(params,
+			"response="+quoteString(h(ha1, nonce, nc, cnonce, qop, ha2)),
+			"qop="+qop,
+			"nc="+nc,
+			"cnonce="+quoteString(cnonce),
+		)
+	}
+	if algorithm != "" {
+		params = append(params, "algorithm="+algorithm)
+	}
+	if opaque, ok := s.challenge["opaque"]; ok {
+		params = append(params, "opaque="+quoteString(opaque))
+	}
+
+	r.Header.Set("Authorization", "Digest "+strings.Join(params, ", "))
+	return nil
+}
+
+func digestHash(algorithm string) (func() hash.Hash, error) {
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "", "MD5":
+		return md5.New, nil
+	case "SHA-256":
+		return sha256.New, nil
+	case "SHA-512-256":
+		return sha512.New512_256, nil
+	}
+	return nil, fmt.Errorf("unsupported digest algorithm: %s", algorithm)
+}
+
+// Returns the quality of protection to use, preferring auth over auth-int.
+func selectQOP(value string) string {
+	qop := ""
+	for _, v := range strings.Split(value, ",") {
+		switch strings.TrimSpace(v) {
+		case "auth":
+			return "auth"
+		case "auth-int":
+			qop = "auth-int"
+		}
+	}
+	return qop
+}
+
+// authChallenge is a challenge of a WWW-Authenticate header.
+type authChallenge struct {
+	scheme string
+	params map[string]string
+}
+
+// Parses the challenges of a WWW-Authenticate header, as in RFC 9110,
+// section 11.6.1. Both the challenges and their parameters are separated
+// by commas, so a parameter ends the challenge if it is not key=value,
+// e.g. in `Basic realm="a", Digest realm="b", nonce="c"`.
+func parseChallenges(s string) []authChallenge {
+	challenges := []authChallenge{}
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		scheme, rest := cutToken(s)
+		if scheme == "" {
+			return challenges
+		}
+
+		c := authChallenge{scheme: scheme, params: map[string]string{}}
+		rest = strings.TrimLeft(rest, " \t")
+		if token, after, ok := cutToken68(rest); ok {
+			c.params[""] = token
+			rest = after
+		} else {
+			c.params, rest = cutAuthParams(rest)
+		}
+		challenges = append(challenges, c)
+		s = rest
+	}
+}
+
+// Parses the comma separated key=value parameters of an authentication
+// challenge, where the values may be quoted.
+func parseAuthParams(s string) map[string]string {
+	params, _ := cutAuthParams(s)
+	return params
+}
+
+// Returns the key=value parameters at the start of s,
+// and the rest of s, which starts with the next challenge if any.
+func cutAuthParams(s string) (map[string]string, string) {
+	params := map[string]string{}
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		key, rest := cutToken(s)
+		rest = strings.TrimLeft(rest, " \t")
+		if key == "" || !strings.HasPrefix(rest, "=") {
+			return params, s
+		}
+
+		rest = strings.TrimLeft(rest[1:], " \t")
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				b.WriteByte(rest[i])
+			}
+			value = b.String()
+			rest = rest[min(i+1, len(rest)):]
+		} else {
+			value, rest = cutToken(rest)
+		}
+		params[strings.ToLower(key)] = value
+		s = rest
+	}
+}
+
+// Returns the token at the start of s and the rest of s.
+func cutToken(s string) (string, string) {
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !isTokenChar(r)
+	})
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
+
+// Returns the token68 at the start of s, e.g. base64 data, if it
+// is followed by the end of the challenge, and the rest of s.
+func cutToken68(s string) (string, string, bool) {
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !(r < utf8.RuneSelf &&
+			('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' ||
+				strings.ContainsRune("-._~+/", r)))
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	for i < len(s) && s[i] == '=' {
+		i++
+	}
+
+	rest := strings.TrimLeft(s[i:], " \t")
+	if i == 0 || (rest != "" && rest[0] != ',') {
+		return "", s, false
+	}
+	return s[:i], rest, true
+}
+
+// Returns s as a quoted-string, as in RFC 9110, section 5.6.4,
+// where only quotes and backslashes are escaped.
+func quoteString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBasicSigner(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://localhost", nil)
+	err := NewBasicSigner("user", "pass").Sign(req, nil)
+	require.NoError(t, err)
+
+	username, password, ok := req.BasicAuth()
+	require.True(t, ok)
+	require.Equal(t, "user", username)
+	require.Equal(t, "pass", password)
+}
+
+func TestAPIKeySigner(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://localhost?a=b", nil)
+
+	signer, err := NewAPIKeySigner("secret", "X-Key", APIKeyInHeader)
+	require.NoError(t, err)
+	require.NoError(t, signer.Sign(req, nil))
+	require.Equal(t, "secret", req.Header.Get("X-Key"))
+
+	signer, err = NewAPIKeySigner("secret", "key", APIKeyInQuery)
+	require.NoError(t, err)
+	require.NoError(t, signer.Sign(req, nil))
+	require.Equal(t, "secret", req.URL.Query().Get("key"))
+	require.Equal(t, "b", req.URL.Query().Get("a"))
+
+	// The existing query is neither sorted nor re-encoded
+	req, _ = http.NewRequest("GET", "http://localhost?z=1&a=%2f&flag", nil)
+	require.NoError(t, signer.Sign(req, nil))
+	require.Equal(t, "z=1&a=%2f&flag&key=secret", req.URL.RawQuery)
+
+	req, _ = http.NewRequest("GET", "http://localhost", nil)
+	require.NoError(t, signer.Sign(req, nil))
+	require.Equal(t, "key=secret", req.URL.RawQuery)
+
+	_, err = NewAPIKeySigner("secret", "key", "body")
+	require.Error(t, err)
+}
+
+func TestParseAuthParams(t *testing.T) {
+	params := parseAuthParams(`realm="test@host.com", qop="auth,auth-int", nonce="abc", opaque="x\"y", algorithm=MD5`)
+	require.Equal(t, map[string]string{
+		"realm":     "test@host.com",
+		"qop":       "auth,auth-int",
+		"nonce":     "abc",
+		"opaque":    `x"y`,
+		"algorithm": "MD5",
+	}, params)
+}
+
+func TestDigestSigner(t *testing.T) {
+	signer := NewDigestSigner("Mufasa", "Circle of Life")
+	req, _ := http.NewRequest("GET", "http://localhost/dir/index.html", nil)
+
+	// No challenge yet
+	require.NoError(t, signer.Sign(req, nil))
+	require.Empty(t, req.Header.Get("Authorization"))
+
+	res := &http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Header: http.Header{"Www-Authenticate": {
+			`Basic realm="x"`,
+			`Digest realm="http-auth@example.org", qop="auth", nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`,
+		}},
+	}
+	retry, err := signer.Challenge(res)
+	require.NoError(t, err)
+	require.True(t, retry)
+
+	require.NoError(t, signer.Sign(req, nil))
+	auth := req.Header.Get("Authorization")
+	require.True(t, strings.HasPrefix(auth, "Digest "))
+
+	params := parseAuthParams(strings.TrimPrefix(auth, "Digest "))
+	h := func(s string) string {
+		sum := md5.Sum([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	ha1 := h("Mufasa:http-auth@example.org:Circle of Life")
+	ha2 := h("GET:/dir/index.html")
+	expected := h(fmt.Sprintf("%s:%s:%s:%s:auth:%s", ha1, params["nonce"], params["nc"], params["cnonce"], ha2))
+	require.Equal(t, expected, params["response"])
+	require.Equal(t, "00000001", params["nc"])
+	require.Equal(t, "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS", params["opaque"])
+
+	// A second challenge means the credentials were rejected
+	retry, err = signer.Challenge(res)
+	require.NoError(t, err)
+	require.False(t, retry)
+}
+
+func TestParseChallenges(t *testing.T) {
+	challenges := parseChallenges(`Basic realm="a, b", charset="UTF-8", Bearer abc+/==, Digest realm="c", nonce="d", qop="auth,auth-int", Negotiate`)
+	require.Equal(t, []authChallenge{
+		{"Basic", map[string]string{"realm": "a, b", "charset": "UTF-8"}},
+		{"Bearer", map[string]string{"": "abc+/=="}},
+		{"Digest", map[string]string{"realm": "c", "nonce": "d", "qop": "auth,auth-int"}},
+		{"Negotiate", map[string]string{}},
+	}, challenges)
+}
+
+func TestDigestSignerChallengesInOneHeader(t *testing.T) {
+	signer := NewDigestSigner("Müfasa", "Circle of Life")
+	res := &http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Header: http.Header{"Www-Authenticate": {
+			`Basic realm="x", Digest realm="a \"quoted\" realm", qop="auth", nonce="abc"`,
+		}},
+	}
+	retry, err := signer.Challenge(res)
+	require.NoError(t, err)
+	require.True(t, retry)
+
+	req, _ := http.NewRequest("GET", "http://localhost/", nil)
+	require.NoError(t, signer.Sign(req, nil))
+	auth := req.Header.Get("Authorization")
+	require.Contains(t, auth, `username="Müfasa"`)
+	require.Contains(t, auth, `realm="a \"quoted\" realm"`)
+	require.Contains(t, auth, `nonce="abc"`)
+}
+
+func TestDigestSignerUnsupportedAlgorithm(t *testing.T) {
+	signer := NewDigestSigner("user", "pass")
+	res := &http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Header:     http.Header{"Www-Authenticate": {`Digest realm="x", nonce="y", algorithm=SHA-1`}},
+	}
+	_, err := signer.Challenge(res)
+	require.Error(t, err)
+
+	u, _ := url.Parse("http://localhost")
+	require.NoError(t, signer.Sign(&http.Request{URL: u, Header: http.Header{}}, nil))
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Body is the body of a request, which is read when the request is sent.
+// It is opened again if the request is redirected or retried.
+type Body interface {
+	// Open returns a reader of the body from the start.
+	// The reader is an io.ReadSeeker if the body is seekable.
+	Open() (io.ReadCloser, error)
+	// Len returns the length of the body in bytes, or -1 if unknown.
+	Len() int64
+}
+
+// BytesBody is a body held in memory.
+type BytesBody []byte
+
+func (b BytesBody) Open() (io.ReadCloser, error) {
+	return nopCloser{bytes.NewReader(b)}, nil
+}
+
+func (b BytesBody) Len() int64 {
+	return int64(len(b))
+}
+
+// FileBody is the content of a file, which is read when the request is sent.
+type FileBody struct {
+	path string
+	size int64
+}
+
+func NewFileBody(path string) (*FileBody, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("not a regular file: %s", path)
+	}
+	return &FileBody{path: path, size: info.Size()}, nil
+}
+
+func (f *FileBody) Open() (io.ReadCloser, error) {
+	return os.Open(f.path)
+}
+
+func (f *FileBody) Len() int64 {
+	return f.size
+}
+
+var errStreamRead = errors.New("request body is a stream that has already been read")
+
+// StreamBody is a body of unknown length, e.g. stdin, that is sent with
+// chunked transfer encoding. It can only be read once, so the request
+// cannot be redirected with the body or retried.
+type StreamBody struct {
+	r    io.Reader
+	read bool
+}
+
+func NewStreamBody(r io.Reader) *StreamBody {
+	return &StreamBody{r: r}
+}
+
+func (s *StreamBody) Open() (io.ReadCloser, error) {
+	if s.read {
+		return nil, errStreamRead
+	}
+	s.read = true
+	return io.NopCloser(s.r), nil
+}
+
+func (s *StreamBody) Len() int64 {
+	return -1
+}
+
+// ReadAll reads the rest of the stream into memory.
+func (s *StreamBody) ReadAll() (BytesBody, error) {
+	if s.read {
+		return nil, errStreamRead
+	}
+	s.read = true
+	return io.ReadAll(s.r)
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error { return nil }
+
+// multiReaderAt reads the sections one after another, as if they were one.
+type multiReaderAt []*io.SectionReader
+
+func (m multiReaderAt) size() int64 {
+	var size int64
+	for _, s := range m {
+		size += s.Size()
+	}
+	return size
+}
+
+func (m multiReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n := 0
+	for _, s := range m {
+		size := s.Size()
+		if off >= size {
+			off -= size
+			continue
+		}
+
+		k, err := s.ReadAt(p[n:], off)
+		n += k
+		if n == len(p) {
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+		if int64(k) < size-off {
+			// The underlying reader, e.g. a file, is shorter than expected
+			return n, io.ErrUnexpectedEOF
+		}
+		off = 0
+	}
+	return n, io.EOF
+}
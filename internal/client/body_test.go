@@ -0,0 +1,94 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "body.txt")
+	require.NoError(t, os.WriteFile(path, []byte("content"), 0644))
+
+	body, err := NewFileBody(path)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), body.Len())
+
+	r, err := body.Open()
+	require.NoError(t, err)
+	defer r.Close()
+	_, ok := r.(io.ReadSeeker)
+	require.True(t, ok, "file body is seekable")
+
+	_, err = NewFileBody(t.TempDir())
+	require.Error(t, err)
+}
+
+func TestStreamBody(t *testing.T) {
+	body := NewStreamBody(strings.NewReader("content"))
+	require.Equal(t, int64(-1), body.Len())
+
+	r, err := body.Open()
+	require.NoError(t, err)
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "content", string(b))
+
+	_, err = body.Open()
+	require.Error(t, err)
+	_, err = body.ReadAll()
+	require.Error(t, err)
+}
+
+func TestSendStreamBody(t *testing.T) {
+	var received string
+	var transferEncoding []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		transferEncoding = r.TransferEncoding
+		b, _ := io.ReadAll(r.Body)
+		received = string(b)
+	}))
+	defer srv.Close()
+
+	client := setupClient(t)
+	u, err := parseURL(srv.URL)
+	require.NoError(t, err)
+
+	req, err := client.BuildBodyRequest(http.MethodPost, u, NewStreamBody(strings.NewReader("streamed")), nil)
+	require.NoError(t, err)
+	res, err := client.Send(req)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	require.Equal(t, "streamed", received)
+	require.Equal(t, []string{"chunked"}, transferEncoding)
+}
+
+func TestSendExpectContinue(t *testing.T) {
+	var expect string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expect = r.Header.Get("Expect")
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	}))
+	defer srv.Close()
+
+	client := setupClient(t)
+	u, err := parseURL(srv.URL)
+	require.NoError(t, err)
+
+	header := http.Header{"Expect": {"100-continue"}}
+	req, err := client.BuildBodyRequest(http.MethodPost, u, BytesBody("content"), header)
+	require.NoError(t, err)
+	res, err := client.Send(req)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	require.Equal(t, "100-continue", expect)
+	require.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+}
@@ -7,7 +7,9 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"os"
 	"time"
+	"unicode/utf8"
 
 	"net/http/httptrace"
 	"strings"
@@ -15,32 +17,13 @@ import (
 	"github.com/lunjon/http/internal/types"
 )
 
-func init() {
-	supportedMethods = make(map[string]bool)
-	for _, m := range SupportedMethods {
-		supportedMethods[m] = true
-	}
-}
-
-var (
-	supportedMethods = map[string]bool{}
-	SupportedMethods = []string{
-		http.MethodGet,
-		http.MethodPost,
-		http.MethodPut,
-		http.MethodDelete,
-		http.MethodHead,
-		http.MethodOptions,
-		http.MethodPatch,
-	}
-)
-
 type Client struct {
 	httpClient   *http.Client
 	tracer       *Tracer
 	clientTrace  *httptrace.ClientTrace
 	clientLogger *log.Logger
 	settings     Settings
+	keyLog       *os.File
 }
 
 func NewClient(
@@ -50,29 +33,48 @@ func NewClient(
 ) (*Client, error) {
 	t := newTracer(traceLogger)
 	trace := &httptrace.ClientTrace{
-		DNSStart:          t.DNSStart,
-		DNSDone:           t.DNSDone,
-		ConnectStart:      t.ConnectStart,
-		ConnectDone:       t.ConnectDone,
-		TLSHandshakeStart: t.TLSHandshakeStart,
-		TLSHandshakeDone:  t.TLSHandshakeDone,
+		DNSStart:             t.DNSStart,
+		DNSDone:              t.DNSDone,
+		ConnectStart:         t.ConnectStart,
+		ConnectDone:          t.ConnectDone,
+		TLSHandshakeStart:    t.TLSHandshakeStart,
+		TLSHandshakeDone:     t.TLSHandshakeDone,
+		GotConn:              t.GotConn,
+		WroteRequest:         t.WroteRequest,
+		GotFirstResponseByte: t.GotFirstResponseByte,
+	}
+
+	settings, keyLog, err := settings.openKeyLog()
+	if err != nil {
+		return nil, err
 	}
 
 	httpClient, err := settings.BuildHTTPClient()
+	if err != nil && keyLog != nil {
+		keyLog.Close()
+	}
 	return &Client{
 		httpClient:   httpClient,
 		tracer:       t,
 		clientLogger: clientLogger,
 		clientTrace:  trace,
 		settings:     settings,
+		keyLog:       keyLog,
 	}, err
 }
 
+// Close closes the key log file of the client, if any.
+func (client *Client) Close() error {
+	if client.keyLog == nil {
+		return nil
+	}
+	return client.keyLog.Close()
+}
+
 func (client *Client) BuildRequest(method string, u *url.URL, body []byte, header http.Header) (*http.Request, error) {
 	method = strings.ToUpper(strings.TrimSpace(method))
-	supported, found := supportedMethods[method]
-	if !(supported && found) {
-		return nil, fmt.Errorf("invalid or unsupported method: %s", method)
+	if !ValidMethod(method) {
+		return nil, fmt.Errorf("invalid method: %q", method)
 	}
 
 	var b io.Reader
@@ -94,45 +96,150 @@ func (client *Client) BuildRequest(method string, u *url.URL, body []byte, heade
 	return req, nil
 }
 
-func (client *Client) Send(req *http.Request) (*http.Response, error) {
-	req = req.WithContext(httptrace.WithClientTrace(req.Context(), client.clientTrace))
+// ValidMethod returns true if method is a valid HTTP method, which is
+// any token as defined by RFC 9110, e.g. GET, PROPFIND or QUERY.
+func ValidMethod(method string) bool {
+	if method == "" {
+		return false
+	}
+	for _, r := range method {
+		if !isTokenChar(r) {
+			return false
+		}
+	}
+	return true
+}
 
-	client.clientLogger.Printf("Sending request: %s %s", req.Method, req.URL.String())
-	if len(req.Header) > 0 {
-		taber := types.NewTaber("  ")
-		taber.Writef("Request headers:\n")
-		for name, value := range req.Header {
-			line := []string{name + ":"}
-			line = append(line, value...)
-			taber.WriteLine(line...)
+func isTokenChar(r rune) bool {
+	return r < utf8.RuneSelf &&
+		('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' ||
+			strings.ContainsRune("!#$%&'*+-.^_`|~", r))
+}
+
+// BuildBodyRequest returns a request like BuildRequest, but with a body
+// that is read when sent, and opened again when redirected or retried.
+func (client *Client) BuildBodyRequest(method string, u *url.URL, body Body, header http.Header) (*http.Request, error) {
+	req, err := client.BuildRequest(method, u, nil, header)
+	if err != nil || body == nil {
+		return req, err
+	}
+
+	client.clientLogger.Print("Using request body")
+	req.ContentLength = body.Len()
+	if req.ContentLength < 0 {
+		client.clientLogger.Print("Using chunked transfer encoding since the length of the body is unknown")
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		if body.Len() == 0 {
+			return http.NoBody, nil
 		}
-		client.clientLogger.Print(taber.String())
+		return body.Open()
 	}
+	req.Body, err = req.GetBody()
+	return req, err
+}
 
-	start := time.Now()
-	res, err := client.httpClient.Do(req)
-	elapsed := time.Since(start)
+func (client *Client) Send(req *http.Request) (*http.Response, error) {
+	ctx := httptrace.WithClientTrace(req.Context(), client.clientTrace)
+	req = req.WithContext(withTracer(ctx, client.tracer))
+
+	// The header is cloned since it is modified, and the
+	// request may be used concurrently by the caller.
+	req.Header = req.Header.Clone()
+	client.setAcceptEncoding(req)
+	client.clientLogger.Printf("Sending request: %s %s", req.Method, req.URL.String())
+	client.logHeader("Request headers", req.Header)
+
+	client.tracer.reset()
+	res, err := client.do(req)
 
 	if err != nil {
 		client.clientLogger.Printf("Request failed: %v", err)
 		return nil, err
 	}
 
+	hops := Hops(res)
+	for _, hop := range hops[:len(hops)-1] {
+		client.clientLogger.Printf("Redirected: %d %s %s -> %s (%v)",
+			hop.Status, hop.Method, hop.URL, hop.Location, hop.Total.Round(time.Microsecond))
+	}
+
 	client.clientLogger.Printf("Response status: %s", res.Status)
-	client.tracer.Report(elapsed)
-
-	if len(res.Header) > 0 {
-		taber := types.NewTaber("  ")
-		taber.Writef("Response headers:\n")
-		for name, value := range res.Header {
-			line := []string{name + ":"}
-			line = append(line, value...)
-			taber.WriteLine(line...)
+	client.clientLogger.Printf("Protocol: %s", res.Proto)
+	client.tracer.Report()
+
+	client.logHeader("Response headers", res.Header)
+	decodeBody(res, client.clientLogger)
+	return res, err
+}
+
+// Timings returns the timing of each hop of the last request sent.
+// It should be called after the response body has been read.
+func (client *Client) Timings() []Timing {
+	return client.tracer.Timings()
+}
+
+// do sends the request, retrying it according to the retry settings.
+func (client *Client) do(req *http.Request) (*http.Response, error) {
+	retry := client.settings.Retry
+	start := time.Now()
+	attempt := 1
+	for {
+		res, err := client.httpClient.Do(req)
+		reason := retry.shouldRetry(res, err)
+		if reason == "" || attempt > retry.Max {
+			if retry.Max > 0 {
+				client.clientLogger.Printf("Attempts: %d", attempt)
+			}
+			return res, err
+		}
+
+		delay := retry.backoff(attempt, res)
+		if retry.MaxTime > 0 && time.Since(start)+delay > retry.MaxTime {
+			client.clientLogger.Printf("Not retrying since the maximum retry time of %v would be exceeded", retry.MaxTime)
+			client.clientLogger.Printf("Attempts: %d", attempt)
+			return res, err
+		}
+
+		// Rewind the body so that it can be sent again
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				client.clientLogger.Print("Not retrying since the request body cannot be rewound")
+				return res, err
+			}
+
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				client.clientLogger.Printf("Not retrying since the request body could not be rewound: %v", bodyErr)
+				return res, err
+			}
+			req.Body = body
+		}
+
+		if res != nil {
+			_, _ = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
 		}
-		client.clientLogger.Print(taber.String())
+
+		client.clientLogger.Printf("Attempt %d failed (%s), retrying in %v", attempt, reason, delay)
+		time.Sleep(delay)
+		attempt++
 	}
+}
 
-	return res, err
+func (client *Client) logHeader(heading string, header http.Header) {
+	if len(header) == 0 {
+		return
+	}
+
+	taber := types.NewTaber("  ")
+	taber.Writef("%s:\n", heading)
+	for name, value := range header {
+		line := []string{name + ":"}
+		line = append(line, value...)
+		taber.WriteLine(line...)
+	}
+	client.clientLogger.Print(taber.String())
 }
 
 func (client *Client) Settings() Settings {
@@ -2,6 +2,9 @@ package client
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/lunjon/http/internal/logging"
@@ -30,9 +33,13 @@ func TestBuildRequest(t *testing.T) {
 		{"HEAD", "http://localhost/path", `{}`, false},
 		{"Put", "http://localhost/path", `{"name": "lol"}`, false},
 		{"Patch", "http://localhost/path", `{"name": "lol"}`, false},
+		{"PROPFIND", "http://localhost/path", "", false},
+		{"query", "http://localhost/path", `{}`, false},
 		// Invalid
 		{"", "", "", true},
-		{"WHAT", "localhost/path", "", true},
+		{"GET /", "localhost/path", "", true},
+		{"MÖVE", "localhost/path", "", true},
+		{"GET(", "localhost/path", "", true},
 	}
 
 	var body []byte
@@ -51,6 +58,15 @@ func TestBuildRequest(t *testing.T) {
 	}
 }
 
+func TestValidMethod(t *testing.T) {
+	for _, method := range []string{"GET", "PROPFIND", "M-SEARCH", "VERSION-CONTROL", "purge", "X_1!"} {
+		require.True(t, ValidMethod(method), method)
+	}
+	for _, method := range []string{"", "GET ", "A/B", "A:B", "{}", "MÖVE", "\x00"} {
+		require.False(t, ValidMethod(method), method)
+	}
+}
+
 func TestClientGet(t *testing.T) {
 	client := setupClient(t)
 	u, err := parseURL(server.URL)
@@ -91,3 +107,48 @@ func TestClientPost(t *testing.T) {
 		})
 	}
 }
+
+func TestClientProtocols(t *testing.T) {
+	h2c := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	h2c.Config.Protocols = new(http.Protocols)
+	h2c.Config.Protocols.SetHTTP1(true)
+	h2c.Config.Protocols.SetUnencryptedHTTP2(true)
+	h2c.Start()
+	defer h2c.Close()
+
+	h2 := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	h2.EnableHTTP2 = true
+	h2.StartTLS()
+	defer h2.Close()
+
+	tests := []struct {
+		url      string
+		protocol Protocol
+		expected string
+	}{
+		{h2c.URL, ProtocolDefault, "HTTP/1.1"},
+		{h2c.URL, ProtocolHTTP2, "HTTP/1.1"},
+		{h2c.URL, ProtocolHTTP2PriorKnowledge, "HTTP/2.0"},
+		{h2.URL, ProtocolDefault, "HTTP/2.0"},
+		{h2.URL, ProtocolHTTP1, "HTTP/1.1"},
+		{h2.URL, ProtocolHTTP2, "HTTP/2.0"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s", tt.url, tt.protocol), func(t *testing.T) {
+			logger := logging.NewLogger()
+			settings := NewSettings().
+				WithProtocol(tt.protocol).
+				WithTLSOptions(NewTLSOptions().WithSkipVerifyInsecure(true))
+			client, err := NewClient(settings, logger, logger)
+			require.NoError(t, err)
+
+			u, _ := url.Parse(tt.url)
+			req, err := client.BuildRequest(http.MethodGet, u, nil, nil)
+			require.NoError(t, err)
+
+			res, err := client.Send(req)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, res.Proto)
+		})
+	}
+}
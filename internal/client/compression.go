@@ -0,0 +1,178 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// defaultAcceptEncoding is requested if no Accept-Encoding header is
+	// given, as done by the transport of the standard library.
+	defaultAcceptEncoding = "gzip"
+	// compressedAcceptEncoding is requested when Settings.Compressed is set.
+	compressedAcceptEncoding = "gzip, deflate, br, zstd"
+)
+
+// ContentEncodings are the supported content encodings, both for
+// decoding responses and for compressing request bodies.
+var ContentEncodings = []string{"gzip", "deflate", "br", "zstd"}
+
+// Compress returns body compressed with the content encoding.
+func Compress(encoding string, body []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(buf)
+	case "deflate":
+		w = zlib.NewWriter(buf)
+	case "br":
+		w = brotli.NewWriter(buf)
+	case "zstd":
+		enc, err := zstd.NewWriter(buf)
+		if err != nil {
+			return nil, err
+		}
+		w = enc
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func newDecoder(encoding string, r io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		return zlib.NewReader(r)
+	case "br":
+		return io.NopCloser(brotli.NewReader(r)), nil
+	case "zstd":
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+}
+
+// setAcceptEncoding requests a compressed response, unless the
+// request already has an Accept-Encoding header. As with the standard
+// library, HEAD and range requests are not compressed by default.
+func (client *Client) setAcceptEncoding(req *http.Request) {
+	if req.Header.Get("Accept-Encoding") != "" {
+		return
+	}
+
+	if client.settings.Compressed {
+		req.Header.Set("Accept-Encoding", compressedAcceptEncoding)
+	} else if req.Method != http.MethodHead && req.Header.Get("Range") == "" {
+		req.Header.Set("Accept-Encoding", defaultAcceptEncoding)
+	}
+}
+
+// decodeBody replaces the body of res with a decoded body if it has
+// a supported content encoding. As with the standard library, the
+// Content-Encoding and Content-Length headers are then removed.
+func decodeBody(res *http.Response, logger *log.Logger) {
+	encoding := res.Header.Get("Content-Encoding")
+	if !slices.Contains(ContentEncodings, encoding) || res.Body == http.NoBody {
+		return
+	}
+
+	wire := &countingReader{r: res.Body}
+	res.Body = &decodedBody{
+		encoding: encoding,
+		wire:     wire,
+		body:     res.Body,
+		logger:   logger,
+	}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// decodedBody decodes the body as it is read, and logs the
+// size on the wire and decoded when read to completion.
+type decodedBody struct {
+	encoding string
+	wire     *countingReader
+	body     io.Closer
+	logger   *log.Logger
+
+	decoder io.ReadCloser
+	size    int64
+	err     error
+	logged  bool
+}
+
+func (d *decodedBody) Read(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+
+	if d.decoder == nil {
+		// The decoder is created lazily since it may read from
+		// the body, which is empty for e.g. 304 responses.
+		d.decoder, d.err = newDecoder(d.encoding, d.wire)
+		if d.err != nil {
+			if d.err != io.EOF {
+				d.err = fmt.Errorf("decoding %s response: %w", d.encoding, d.err)
+			}
+			return 0, d.err
+		}
+	}
+
+	n, err := d.decoder.Read(p)
+	d.size += int64(n)
+	if err == io.EOF {
+		d.logSize()
+	}
+	return n, err
+}
+
+func (d *decodedBody) Close() error {
+	if d.decoder != nil {
+		d.decoder.Close()
+	}
+	return d.body.Close()
+}
+
+func (d *decodedBody) logSize() {
+	if d.logged {
+		return
+	}
+	d.logged = true
+	d.logger.Printf("Response body: %d bytes on the wire, %d bytes decoded (%s)", d.wire.n, d.size, d.encoding)
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lunjon/http/internal/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompress(t *testing.T) {
+	body := []byte(strings.Repeat("compress me ", 100))
+	for _, encoding := range ContentEncodings {
+		t.Run(encoding, func(t *testing.T) {
+			compressed, err := Compress(encoding, body)
+			require.NoError(t, err)
+			require.Less(t, len(compressed), len(body))
+
+			decoder, err := newDecoder(encoding, strings.NewReader(string(compressed)))
+			require.NoError(t, err)
+			decoded, err := io.ReadAll(decoder)
+			require.NoError(t, err)
+			require.Equal(t, body, decoded)
+		})
+	}
+
+	_, err := Compress("unknown", body)
+	require.Error(t, err)
+}
+
+func TestClientDecodesResponse(t *testing.T) {
+	body := strings.Repeat("decode me ", 100)
+	var acceptEncoding string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		encoding := r.URL.Query().Get("encoding")
+		compressed, _ := Compress(encoding, []byte(body))
+		w.Header().Set("Content-Encoding", encoding)
+		w.Write(compressed)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		encoding       string
+		compressed     bool
+		header         string
+		acceptEncoding string
+	}{
+		{"gzip", false, "", "gzip"},
+		{"br", false, "br", "br"},
+		{"zstd", true, "", "gzip, deflate, br, zstd"},
+		{"deflate", true, "deflate", "deflate"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.encoding, func(t *testing.T) {
+			logs := &strings.Builder{}
+			logger := logging.New(logs)
+			client, err := NewClient(NewSettings().WithCompressed(test.compressed), logger, logger)
+			require.NoError(t, err)
+
+			header := http.Header{}
+			if test.header != "" {
+				header.Set("Accept-Encoding", test.header)
+			}
+			url, _ := ParseURL(srv.URL+"?encoding="+test.encoding, nil)
+			req, err := client.BuildRequest("GET", url, nil, header)
+			require.NoError(t, err)
+
+			res, err := client.Send(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			b, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.Equal(t, body, string(b))
+			require.Equal(t, test.acceptEncoding, acceptEncoding)
+			require.Empty(t, res.Header.Get("Content-Encoding"))
+			require.Contains(t, logs.String(), "1000 bytes decoded ("+test.encoding+")")
+		})
+	}
+}
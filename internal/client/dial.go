@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// ResolveOverride makes connections to Host and Port use Addrs
+// instead of resolving the host, like curl's --resolve.
+type ResolveOverride struct {
+	Host  string
+	Port  string
+	Addrs []string
+}
+
+// ParseResolveOverride parses a value in the format host:port:addr[,addr]...
+// IPv6 addresses are enclosed in brackets.
+func ParseResolveOverride(s string) (ResolveOverride, error) {
+	fields := splitHostFields(s)
+	if len(fields) != 3 || fields[0] == "" || fields[1] == "" || fields[2] == "" {
+		return ResolveOverride{}, fmt.Errorf("invalid resolve override, expected host:port:addr: %s", s)
+	}
+
+	override := ResolveOverride{
+		Host: strings.ToLower(unbracket(fields[0])),
+		Port: fields[1],
+	}
+	for _, addr := range strings.Split(fields[2], ",") {
+		addr = unbracket(strings.TrimSpace(addr))
+		if net.ParseIP(addr) == nil {
+			return ResolveOverride{}, fmt.Errorf("invalid address in resolve override: %s", addr)
+		}
+		override.Addrs = append(override.Addrs, addr)
+	}
+	return override, nil
+}
+
+// ConnectToOverride makes connections to Host and Port go to ToHost
+// and ToPort instead, like curl's --connect-to. An empty Host or Port
+// matches any, and an empty ToHost or ToPort keeps the original.
+type ConnectToOverride struct {
+	Host   string
+	Port   string
+	ToHost string
+	ToPort string
+}
+
+// ParseConnectToOverride parses a value in the format host1:port1:host2:port2,
+// where any of the parts may be empty.
+func ParseConnectToOverride(s string) (ConnectToOverride, error) {
+	fields := splitHostFields(s)
+	if len(fields) != 4 {
+		return ConnectToOverride{}, fmt.Errorf("invalid connect-to override, expected host1:port1:host2:port2: %s", s)
+	}
+	return ConnectToOverride{
+		Host:   strings.ToLower(unbracket(fields[0])),
+		Port:   fields[1],
+		ToHost: unbracket(fields[2]),
+		ToPort: fields[3],
+	}, nil
+}
+
+func (o ConnectToOverride) matches(host, port string) bool {
+	return (o.Host == "" || o.Host == host) && (o.Port == "" || o.Port == port)
+}
+
+// DialOptions overrides how the addresses of connections are resolved.
+type DialOptions struct {
+	Resolve   []ResolveOverride
+	ConnectTo []ConnectToOverride
+	// DNSServer is the address of the DNS server to use
+	// instead of the system resolver. The port defaults to 53.
+	DNSServer string
+	// UnixSocket is the path of a Unix domain socket that
+	// all connections are made to, if set.
+	UnixSocket string
+}
+
+// Returns a dial function applying the overrides. The original host is
+// still used for the Host header and TLS server name, since only the
+// address that is dialed changes.
+func (opts DialOptions) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if opts.DNSServer != "" {
+		server := opts.DNSServer
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(unbracket(server), "53")
+		}
+		dialer.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				d := net.Dialer{Timeout: time.Second * 5}
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		tracer := tracerFromContext(ctx)
+		if opts.UnixSocket != "" {
+			tracer.DialOverride("Connecting to unix socket %s instead of %s", opts.UnixSocket, addr)
+			return dialer.DialContext(ctx, "unix", opts.UnixSocket)
+		}
+
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		host = strings.ToLower(host)
+
+		for _, o := range opts.ConnectTo {
+			if !o.matches(host, port) {
+				continue
+			}
+			if o.ToHost != "" {
+				host = strings.ToLower(o.ToHost)
+			}
+			if o.ToPort != "" {
+				port = o.ToPort
+			}
+			tracer.DialOverride("Connecting to %s instead of %s", net.JoinHostPort(host, port), addr)
+			break
+		}
+
+		for _, o := range opts.Resolve {
+			if o.Host != host || o.Port != port {
+				continue
+			}
+			tracer.DialOverride("Resolved %s to %s using override", host, strings.Join(o.Addrs, ", "))
+
+			var conn net.Conn
+			for _, ip := range o.Addrs {
+				if conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip, port)); err == nil {
+					return conn, nil
+				}
+			}
+			return nil, err
+		}
+
+		if opts.DNSServer != "" {
+			tracer.DialOverride("Resolving %s using DNS server %s", host, opts.DNSServer)
+		}
+		return dialer.DialContext(ctx, network, net.JoinHostPort(host, port))
+	}
+}
+
+// Splits s on colons that are not enclosed in brackets.
+func splitHostFields(s string) []string {
+	fields := []string{}
+	depth := 0
+	start := 0
+	for i, c := range s {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ':':
+			if depth == 0 {
+				fields = append(fields, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(fields, s[start:])
+}
+
+func unbracket(s string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+}
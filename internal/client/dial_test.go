@@ -0,0 +1,101 @@
+package client
+
+import (
+	"bytes"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/lunjon/http/internal/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResolveOverride(t *testing.T) {
+	o, err := ParseResolveOverride("Example.com:443:127.0.0.1,[::1]")
+	require.NoError(t, err)
+	require.Equal(t, ResolveOverride{Host: "example.com", Port: "443", Addrs: []string{"127.0.0.1", "::1"}}, o)
+
+	for _, s := range []string{"", "example.com", "example.com:443", "example.com:443:host", ":443:127.0.0.1"} {
+		_, err := ParseResolveOverride(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestParseConnectToOverride(t *testing.T) {
+	o, err := ParseConnectToOverride("example.com:443:[::1]:8443")
+	require.NoError(t, err)
+	require.Equal(t, ConnectToOverride{Host: "example.com", Port: "443", ToHost: "::1", ToPort: "8443"}, o)
+
+	o, err = ParseConnectToOverride("::backend:")
+	require.NoError(t, err)
+	require.True(t, o.matches("any", "80"))
+	require.Equal(t, "backend", o.ToHost)
+
+	_, err = ParseConnectToOverride("example.com:443:backend")
+	require.Error(t, err)
+}
+
+func TestClientDialOverrides(t *testing.T) {
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	resolve, err := ParseResolveOverride("api.test:" + port + ":127.0.0.1")
+	require.NoError(t, err)
+	connectTo, err := ParseConnectToOverride("backend.test:80:127.0.0.1:" + port)
+	require.NoError(t, err)
+
+	tests := []struct {
+		url  string
+		opts DialOptions
+		log  string
+	}{
+		{"http://api.test:" + port, DialOptions{Resolve: []ResolveOverride{resolve}}, "Resolved api.test to 127.0.0.1"},
+		{"http://backend.test", DialOptions{ConnectTo: []ConnectToOverride{connectTo}}, "Connecting to 127.0.0.1:" + port},
+	}
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			settings := NewSettings().WithDialOptions(test.opts)
+			client, err := NewClient(settings, logging.NewLogger(), log.New(buf, "", 0))
+			require.NoError(t, err)
+
+			u, _ := url.Parse(test.url)
+			req, err := client.BuildRequest(http.MethodGet, u, nil, nil)
+			require.NoError(t, err)
+
+			res, err := client.Send(req)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, res.StatusCode)
+			require.Contains(t, buf.String(), test.log)
+		})
+	}
+}
+
+func TestClientUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "test.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(&TestServer{})
+	srv.Listener = listener
+	srv.Start()
+	defer srv.Close()
+
+	buf := &bytes.Buffer{}
+	settings := NewSettings().WithUnixSocket(socket)
+	client, err := NewClient(settings, logging.NewLogger(), log.New(buf, "", 0))
+	require.NoError(t, err)
+
+	u, _ := url.Parse("http://localhost/path")
+	req, err := client.BuildRequest(http.MethodGet, u, nil, nil)
+	require.NoError(t, err)
+
+	res, err := client.Send(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Contains(t, buf.String(), "Connecting to unix socket "+socket)
+}
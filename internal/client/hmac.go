@@ -0,0 +1,121 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lunjon/http/internal/config"
+)
+
+// HMACSigner signs requests using an HMAC over the method, path,
+// timestamp and body hash, separated by newlines. The signature
+// and timestamp are set in headers.
+type HMACSigner struct {
+	secret          []byte
+	hash            func() hash.Hash
+	encode          func([]byte) string
+	header          string
+	timestampHeader string
+	keyID           string
+	keyIDHeader     string
+	bodyHashHeader  string
+	now             func() time.Time
+}
+
+func newHMACSignerFromConfig(cfg config.Signer) (RequestSigner, error) {
+	secret, err := cfg.Secret("secret")
+	if err != nil {
+		return nil, err
+	}
+
+	signer := &HMACSigner{
+		secret: []byte(secret),
+		now:    time.Now,
+	}
+
+	algorithm, err := cfg.String("algorithm", "sha256")
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(algorithm) {
+	case "sha256":
+		signer.hash = sha256.New
+	case "sha512":
+		signer.hash = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
+
+	encoding, err := cfg.String("encoding", "hex")
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(encoding) {
+	case "hex":
+		signer.encode = hex.EncodeToString
+	case "base64":
+		signer.encode = base64.StdEncoding.EncodeToString
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %s", encoding)
+	}
+
+	options := []struct {
+		key   string
+		def   string
+		value *string
+	}{
+		{"header", "X-Signature", &signer.header},
+		{"timestamp_header", "X-Timestamp", &signer.timestampHeader},
+		{"key_id", "", &signer.keyID},
+		{"key_id_header", "X-Key-Id", &signer.keyIDHeader},
+		{"body_hash_header", "", &signer.bodyHashHeader},
+	}
+	for _, s := range options {
+		if *s.value, err = cfg.String(s.key, s.def); err != nil {
+			return nil, err
+		}
+	}
+	return signer, nil
+}
+
+func (s *HMACSigner) Sign(r *http.Request, body io.ReadSeeker) error {
+	bodyHash := s.hash()
+	if body != nil {
+		if _, err := io.Copy(bodyHash, body); err != nil {
+			return err
+		}
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	encodedBodyHash := s.encode(bodyHash.Sum(nil))
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+
+	mac := hmac.New(s.hash, s.secret)
+	mac.Write([]byte(strings.Join([]string{
+		r.Method,
+		r.URL.RequestURI(),
+		timestamp,
+		encodedBodyHash,
+	}, "\n")))
+
+	r.Header.Set(s.header, s.encode(mac.Sum(nil)))
+	r.Header.Set(s.timestampHeader, timestamp)
+	if s.keyID != "" {
+		r.Header.Set(s.keyIDHeader, s.keyID)
+	}
+	if s.bodyHashHeader != "" {
+		r.Header.Set(s.bodyHashHeader, encodedBodyHash)
+	}
+	return nil
+}
@@ -10,6 +10,12 @@ const (
 	MIMETypeJSON           MIMEType = "application/json"
 	MIMETypeXML            MIMEType = "application/xml"
 	MIMETypeFormURLEncoded MIMEType = "application/x-www-form-urlencoded"
+	MIMETypeYAML           MIMEType = "application/yaml"
+	MIMETypeNDJSON         MIMEType = "application/x-ndjson"
+	MIMETypeText           MIMEType = "text/plain"
+	MIMETypePDF            MIMEType = "application/pdf"
+	MIMETypePNG            MIMEType = "image/png"
+	MIMETypeProtobuf       MIMEType = "application/x-protobuf"
 	MIMETypeUnknown        MIMEType = "unknown"
 )
 
@@ -0,0 +1,302 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+var tlsVersions = []uint16{
+	tls.VersionTLS10,
+	tls.VersionTLS11,
+	tls.VersionTLS12,
+	tls.VersionTLS13,
+}
+
+// TLSReport describes the TLS configuration of a server.
+type TLSReport struct {
+	Address     string      `json:"address"`
+	ServerName  string      `json:"server_name"`
+	Version     string      `json:"version"`
+	CipherSuite string      `json:"cipher_suite"`
+	ALPN        string      `json:"alpn"`
+	OCSP        *OCSPStatus `json:"ocsp"`
+	// Chain is the certificates sent by the server, starting with the leaf.
+	Chain []CertificateInfo `json:"chain"`
+	// VerificationError is the reason the chain could not be verified, if any.
+	VerificationError string `json:"verification_error,omitempty"`
+	// Accepted lists the TLS versions accepted by the server, if probed.
+	Accepted []AcceptedVersion `json:"accepted,omitempty"`
+}
+
+// OCSPStatus is the status of a stapled OCSP response.
+type OCSPStatus struct {
+	Status     string    `json:"status"`
+	ProducedAt time.Time `json:"produced_at"`
+	NextUpdate time.Time `json:"next_update"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// CertificateInfo describes a certificate in the chain.
+type CertificateInfo struct {
+	Subject            string    `json:"subject"`
+	Issuer             string    `json:"issuer"`
+	SerialNumber       string    `json:"serial_number"`
+	DNSNames           []string  `json:"dns_names,omitempty"`
+	IPAddresses        []string  `json:"ip_addresses,omitempty"`
+	KeyType            string    `json:"key_type"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	DaysToExpiry       int       `json:"days_to_expiry"`
+	IsCA               bool      `json:"is_ca"`
+	SHA256Fingerprint  string    `json:"sha256_fingerprint"`
+	PublicKeyPin       string    `json:"public_key_pin"`
+}
+
+// AcceptedVersion lists the cipher suites a server accepts for a TLS version.
+// Cipher suites of TLS 1.3 cannot be selected, so only the negotiated is listed.
+type AcceptedVersion struct {
+	Version      string   `json:"version"`
+	CipherSuites []string `json:"cipher_suites"`
+}
+
+// InspectTLS performs a TLS handshake with the server at host and port
+// and reports the negotiated parameters and certificate chain. If probe
+// is true, the TLS versions and cipher suites accepted are also reported.
+// The chain is verified separately, so that the report is returned even
+// if verification fails.
+func (s Settings) InspectTLS(ctx context.Context, host, port string, probe bool) (*TLSReport, error) {
+	s, keyLog, err := s.openKeyLog()
+	if err != nil {
+		return nil, err
+	}
+	if keyLog != nil {
+		defer keyLog.Close()
+	}
+
+	tlsConfig, err := s.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = host
+	}
+	if len(tlsConfig.NextProtos) == 0 {
+		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+	}
+	roots := tlsConfig.RootCAs
+	tlsConfig.InsecureSkipVerify = true
+	tlsConfig.VerifyConnection = nil
+
+	addr := net.JoinHostPort(host, port)
+	dial := s.Dial.dialContext(newDialer())
+	handshake := func(config *tls.Config) (tls.ConnectionState, error) {
+		ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+
+		conn, err := dial(ctx, "tcp", addr)
+		if err != nil {
+			return tls.ConnectionState{}, err
+		}
+		defer conn.Close()
+
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return tls.ConnectionState{}, err
+		}
+		return tlsConn.ConnectionState(), nil
+	}
+
+	state, err := handshake(tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TLSReport{
+		Address:     addr,
+		ServerName:  tlsConfig.ServerName,
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ALPN:        state.NegotiatedProtocol,
+		OCSP:        parseOCSPStatus(state),
+	}
+
+	now := time.Now()
+	for _, cert := range state.PeerCertificates {
+		report.Chain = append(report.Chain, newCertificateInfo(cert, now))
+	}
+
+	if err := verifyChain(state.PeerCertificates, roots, tlsConfig.ServerName); err != nil {
+		report.VerificationError = err.Error()
+	}
+
+	if probe {
+		report.Accepted = probeTLS(tlsConfig, handshake)
+	}
+	return report, nil
+}
+
+// Returns the versions and cipher suites accepted by the server.
+func probeTLS(tlsConfig *tls.Config, handshake func(*tls.Config) (tls.ConnectionState, error)) []AcceptedVersion {
+	suites := append(tls.CipherSuites(), tls.InsecureCipherSuites()...)
+	accepted := []AcceptedVersion{}
+
+	for _, version := range tlsVersions {
+		config := tlsConfig.Clone()
+		config.MinVersion = version
+		config.MaxVersion = version
+		state, err := handshake(config)
+		if err != nil {
+			continue
+		}
+
+		names := []string{tls.CipherSuiteName(state.CipherSuite)}
+		if version != tls.VersionTLS13 {
+			names = probeCipherSuites(config, version, suites, handshake)
+		}
+		accepted = append(accepted, AcceptedVersion{
+			Version:      tls.VersionName(version),
+			CipherSuites: names,
+		})
+	}
+	return accepted
+}
+
+// Returns the cipher suites accepted by the server for the
+// version, testing a few suites at a time.
+func probeCipherSuites(
+	tlsConfig *tls.Config,
+	version uint16,
+	suites []*tls.CipherSuite,
+	handshake func(*tls.Config) (tls.ConnectionState, error),
+) []string {
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	sem := make(chan struct{}, 8)
+	names := []string{}
+
+	for _, suite := range suites {
+		if !slices.Contains(suite.SupportedVersions, version) {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			config := tlsConfig.Clone()
+			config.CipherSuites = []uint16{suite.ID}
+			if _, err := handshake(config); err == nil {
+				mu.Lock()
+				names = append(names, suite.Name)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	slices.Sort(names)
+	return names
+}
+
+func verifyChain(chain []*x509.Certificate, roots *x509.CertPool, serverName string) error {
+	if len(chain) == 0 {
+		return fmt.Errorf("no certificates sent by server")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       serverName,
+	})
+	return err
+}
+
+func parseOCSPStatus(state tls.ConnectionState) *OCSPStatus {
+	if len(state.OCSPResponse) == 0 {
+		return nil
+	}
+
+	var issuer *x509.Certificate
+	if len(state.PeerCertificates) > 1 {
+		issuer = state.PeerCertificates[1]
+	}
+
+	res, err := ocsp.ParseResponse(state.OCSPResponse, issuer)
+	if err != nil {
+		return &OCSPStatus{Status: "invalid", Error: err.Error()}
+	}
+
+	status := map[int]string{
+		ocsp.Good:    "good",
+		ocsp.Revoked: "revoked",
+		ocsp.Unknown: "unknown",
+	}[res.Status]
+	return &OCSPStatus{
+		Status:     status,
+		ProducedAt: res.ProducedAt,
+		NextUpdate: res.NextUpdate,
+	}
+}
+
+func newCertificateInfo(cert *x509.Certificate, now time.Time) CertificateInfo {
+	fingerprint := sha256.Sum256(cert.Raw)
+	hexBytes := make([]string, len(fingerprint))
+	for i, b := range fingerprint {
+		hexBytes[i] = fmt.Sprintf("%02X", b)
+	}
+
+	ips := []string{}
+	for _, ip := range cert.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+
+	return CertificateInfo{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		SerialNumber:       cert.SerialNumber.String(),
+		DNSNames:           cert.DNSNames,
+		IPAddresses:        ips,
+		KeyType:            keyType(cert),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		DaysToExpiry:       int(cert.NotAfter.Sub(now).Hours() / 24),
+		IsCA:               cert.IsCA,
+		SHA256Fingerprint:  strings.Join(hexBytes, ":"),
+		PublicKeyPin:       PublicKeyPin(cert),
+	}
+}
+
+func keyType(cert *x509.Certificate) string {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA " + key.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	}
+	return cert.PublicKeyAlgorithm.String()
+}
@@ -0,0 +1,230 @@
+package client
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/lunjon/http/internal/config"
+)
+
+// jwtRegisteredClaims maps the options of a JWT signer
+// to the registered claim names that they set.
+var jwtRegisteredClaims = map[string]string{
+	"issuer":   "iss",
+	"subject":  "sub",
+	"audience": "aud",
+}
+
+// JWTSigner signs requests with a short-lived JSON Web Token,
+// created for each request and signed with RS256, ES256 or HS256.
+type JWTSigner struct {
+	algorithm string
+	sign      func(data []byte) ([]byte, error)
+	keyID     string
+	claims    map[string]any
+	ttl       time.Duration
+	header    string
+	prefix    string
+	now       func() time.Time
+}
+
+func newJWTSignerFromConfig(cfg config.Signer) (RequestSigner, error) {
+	signer := &JWTSigner{now: time.Now}
+
+	var err error
+	if signer.algorithm, err = cfg.String("algorithm", ""); err != nil {
+		return nil, err
+	}
+
+	keyFile, err := cfg.String("key_file", "")
+	if err != nil {
+		return nil, err
+	}
+	if keyFile != "" {
+		if err := signer.loadKey(keyFile); err != nil {
+			return nil, err
+		}
+	} else {
+		secret, err := cfg.Secret("secret")
+		if err != nil {
+			return nil, fmt.Errorf("either key_file or secret must be set: %w", err)
+		}
+		if signer.algorithm != "" && signer.algorithm != "HS256" {
+			return nil, fmt.Errorf("algorithm %s requires key_file", signer.algorithm)
+		}
+		signer.algorithm = "HS256"
+		signer.sign = func(data []byte) ([]byte, error) {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(data)
+			return mac.Sum(nil), nil
+		}
+	}
+
+	if signer.claims, err = cfg.Table("claims"); err != nil {
+		return nil, err
+	}
+	for key, claim := range jwtRegisteredClaims {
+		value, err := cfg.String(key, "")
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			signer.claims[claim] = value
+		}
+	}
+
+	if signer.keyID, err = cfg.String("key_id", ""); err != nil {
+		return nil, err
+	}
+	if signer.ttl, err = cfg.Duration("ttl", time.Minute*5); err != nil {
+		return nil, err
+	}
+	if signer.header, err = cfg.String("header", "Authorization"); err != nil {
+		return nil, err
+	}
+
+	defaultPrefix := ""
+	if http.CanonicalHeaderKey(signer.header) == "Authorization" {
+		defaultPrefix = "Bearer "
+	}
+	if signer.prefix, err = cfg.String("prefix", defaultPrefix); err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+// Loads a private key in PEM format, encoded as PKCS #8, PKCS #1 (RSA) or SEC 1 (EC).
+func (s *JWTSigner) loadKey(filename string) error {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return fmt.Errorf("no PEM data found in %s", filename)
+	}
+
+	var key any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse key in %s: %w", filename, err)
+	}
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		if err := s.setAlgorithm("RS256", filename); err != nil {
+			return err
+		}
+		s.sign = func(data []byte) ([]byte, error) {
+			digest := sha256.Sum256(data)
+			return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		}
+	case *ecdsa.PrivateKey:
+		if key.Curve.Params().BitSize != 256 {
+			return fmt.Errorf("unsupported EC key size: %d", key.Curve.Params().BitSize)
+		}
+		if err := s.setAlgorithm("ES256", filename); err != nil {
+			return err
+		}
+		s.sign = func(data []byte) ([]byte, error) {
+			digest := sha256.Sum256(data)
+			r, sig, err := ecdsa.Sign(rand.Reader, key, digest[:])
+			if err != nil {
+				return nil, err
+			}
+			// JWS uses the fixed size concatenation of r and s.
+			b := make([]byte, 64)
+			r.FillBytes(b[:32])
+			sig.FillBytes(b[32:])
+			return b, nil
+		}
+	default:
+		return fmt.Errorf("unsupported key type in %s: %T", filename, key)
+	}
+	return nil
+}
+
+// setAlgorithm sets the algorithm of the key, unless
+// configured, in which case it must be the same.
+func (s *JWTSigner) setAlgorithm(algorithm, filename string) error {
+	if s.algorithm == "" {
+		s.algorithm = algorithm
+	} else if s.algorithm != algorithm {
+		return fmt.Errorf("algorithm %s does not match the key in %s, which requires %s", s.algorithm, filename, algorithm)
+	}
+	return nil
+}
+
+// Token returns a new signed token.
+func (s *JWTSigner) Token() (string, error) {
+	header := map[string]any{
+		"alg": s.algorithm,
+		"typ": "JWT",
+	}
+	if s.keyID != "" {
+		header["kid"] = s.keyID
+	}
+
+	now := s.now()
+	claims := map[string]any{}
+	for k, v := range s.claims {
+		claims[k] = v
+	}
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(s.ttl).Unix()
+	claims["jti"] = rand.Text()
+
+	encode := func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return base64.RawURLEncoding.EncodeToString(b), nil
+	}
+
+	h, err := encode(header)
+	if err != nil {
+		return "", err
+	}
+	c, err := encode(claims)
+	if err != nil {
+		return "", err
+	}
+
+	data := h + "." + c
+	sig, err := s.sign([]byte(data))
+	if err != nil {
+		return "", err
+	}
+	return data + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func (s *JWTSigner) Sign(r *http.Request, body io.ReadSeeker) error {
+	token, err := s.Token()
+	if err != nil {
+		return err
+	}
+	r.Header.Set(s.header, s.prefix+token)
+	return nil
+}
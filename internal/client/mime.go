@@ -0,0 +1,135 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	extensionMIMETypes = map[string]MIMEType{
+		".html":   MIMETypeHTML,
+		".csv":    MIMETypeCSV,
+		".json":   MIMETypeJSON,
+		".xml":    MIMETypeXML,
+		".yaml":   MIMETypeYAML,
+		".yml":    MIMETypeYAML,
+		".ndjson": MIMETypeNDJSON,
+		".txt":    MIMETypeText,
+		".pdf":    MIMETypePDF,
+		".png":    MIMETypePNG,
+		".proto":  MIMETypeProtobuf,
+	}
+
+	formPattern = regexp.MustCompile(`^[\w.~%+-]+=[^&=\s]*(&[\w.~%+-]+=[^&=\s]*)*$`)
+)
+
+// MIMETypeFromExtension returns the MIME type of a file
+// extension, e.g. ".json", or MIMETypeUnknown.
+func MIMETypeFromExtension(ext string) MIMEType {
+	if mime, found := extensionMIMETypes[strings.ToLower(ext)]; found {
+		return mime
+	}
+	return MIMETypeUnknown
+}
+
+// DetectMIMEType returns the MIME type of the content, by checking if
+// it is valid JSON, newline delimited JSON, XML, an URL encoded form or
+// YAML, in that order. Else the type is detected as by http.DetectContentType,
+// and MIMETypeUnknown is returned for empty content.
+func DetectMIMEType(content []byte) MIMEType {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 {
+		return MIMETypeUnknown
+	}
+
+	detected := http.DetectContentType(trimmed)
+	switch {
+	case json.Valid(trimmed):
+		return MIMETypeJSON
+	case isNDJSON(trimmed):
+		return MIMETypeNDJSON
+	case bytes.HasPrefix(trimmed, []byte("<?xml")):
+		return MIMETypeXML
+	case strings.HasPrefix(detected, "text/html"):
+		return MIMETypeHTML
+	case isXML(trimmed):
+		return MIMETypeXML
+	case formPattern.Match(trimmed):
+		return MIMETypeFormURLEncoded
+	case isYAML(trimmed):
+		return MIMETypeYAML
+	}
+	return MIMEType(detected)
+}
+
+// Returns true if there are at least two lines,
+// and every line that is not empty is valid JSON.
+func isNDJSON(content []byte) bool {
+	lines := 0
+	for line := range bytes.Lines(content) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			return false
+		}
+		lines++
+	}
+	return lines > 1
+}
+
+func isXML(content []byte) bool {
+	if content[0] != '<' {
+		return false
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	elements := 0
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return elements > 0
+		}
+		if err != nil {
+			return false
+		}
+		if _, ok := token.(xml.StartElement); ok {
+			elements++
+		}
+	}
+}
+
+// Returns true if the content is YAML with a mapping or sequence,
+// since almost any text is valid YAML as a scalar.
+func isYAML(content []byte) bool {
+	var node yaml.Node
+	if err := yaml.Unmarshal(content, &node); err != nil || len(node.Content) == 0 {
+		return false
+	}
+
+	kind := node.Content[0].Kind
+	return kind == yaml.MappingNode || kind == yaml.SequenceNode
+}
+
+// DetectStreamMIMEType returns the MIME type of content given only
+// the start of it, e.g. when it is streamed, where JSON and newline
+// delimited JSON are detected by their first lines.
+func DetectStreamMIMEType(start []byte) MIMEType {
+	trimmed := bytes.TrimSpace(start)
+	if i := bytes.LastIndexByte(trimmed, '\n'); i > 0 && isNDJSON(trimmed[:i]) {
+		return MIMETypeNDJSON
+	}
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return MIMETypeJSON
+	}
+	return DetectMIMEType(trimmed)
+}
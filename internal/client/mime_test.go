@@ -0,0 +1,44 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectMIMEType(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    MIMEType
+	}{
+		{"empty", "  \n", MIMETypeUnknown},
+		{"json object", `{"name": "x"}`, MIMETypeJSON},
+		{"json array", ` [1, 2] `, MIMETypeJSON},
+		{"ndjson", "{\"a\":1}\n{\"a\":2}\n", MIMETypeNDJSON},
+		{"html", "<!DOCTYPE html><html><body></body></html>", MIMETypeHTML},
+		{"xml", `<?xml version="1.0"?><user><name>x</name></user>`, MIMETypeXML},
+		{"xml without declaration", `<user name="x"/>`, MIMETypeXML},
+		{"form", "name=x&age=1", MIMETypeFormURLEncoded},
+		{"yaml", "name: x\ntags:\n  - a\n", MIMETypeYAML},
+		{"yaml list", "- a\n- b\n", MIMETypeYAML},
+		{"text", "hello world", "text/plain; charset=utf-8"},
+		{"pdf", "%PDF-1.4 ...", MIMETypePDF},
+		{"png", "\x89PNG\x0D\x0A\x1A\x0A\x00", MIMETypePNG},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, DetectMIMEType([]byte(test.content)))
+		})
+	}
+}
+
+func TestMIMETypeFromExtension(t *testing.T) {
+	require.Equal(t, MIMETypeJSON, MIMETypeFromExtension(".json"))
+	require.Equal(t, MIMETypeYAML, MIMETypeFromExtension(".YML"))
+	require.Equal(t, MIMETypeNDJSON, MIMETypeFromExtension(".ndjson"))
+	require.Equal(t, MIMETypeProtobuf, MIMETypeFromExtension(".proto"))
+	require.Equal(t, MIMETypeUnknown, MIMETypeFromExtension(".unknown"))
+	require.Equal(t, MIMETypeUnknown, MIMETypeFromExtension(""))
+}
@@ -0,0 +1,201 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FormPart is a part of a multipart/form-data body,
+// either a value or the content of a file.
+type FormPart struct {
+	Name  string
+	Value string
+	// File is the path of the file to upload, if any.
+	File string
+	// ContentType of the part. If empty, it is detected
+	// from the file extension or content of the file.
+	ContentType string
+	// Filename sent for a file. Defaults to the name of the file.
+	Filename string
+}
+
+// Multipart is a multipart/form-data body. The content of
+// files is read when the body is read, and not kept in memory.
+type Multipart struct {
+	boundary string
+	sections []section
+}
+
+// section is either data in memory, or a file with the given size.
+type section struct {
+	data []byte
+	file string
+	size int64
+}
+
+// NewMultipart returns a body of the parts, with a random boundary.
+func NewMultipart(parts []FormPart) (*Multipart, error) {
+	buf := bytes.NewBuffer(nil)
+	w := multipart.NewWriter(buf)
+	m := &Multipart{boundary: w.Boundary()}
+
+	for _, part := range parts {
+		if part.File == "" {
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(part.Name)))
+			if part.ContentType != "" {
+				header.Set("Content-Type", part.ContentType)
+			}
+
+			pw, err := w.CreatePart(header)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := pw.Write([]byte(part.Value)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		info, err := os.Stat(part.File)
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("not a regular file: %s", part.File)
+		}
+
+		contentType := part.ContentType
+		if contentType == "" {
+			contentType, err = detectContentType(part.File)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		filename := part.Filename
+		if filename == "" {
+			filename = filepath.Base(part.File)
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(
+			`form-data; name="%s"; filename="%s"`,
+			escapeQuotes(part.Name),
+			escapeQuotes(filename)))
+		header.Set("Content-Type", contentType)
+		if _, err := w.CreatePart(header); err != nil {
+			return nil, err
+		}
+
+		// The content of the file follows the headers of the part
+		m.sections = append(m.sections, section{data: bytes.Clone(buf.Bytes())})
+		m.sections = append(m.sections, section{file: part.File, size: info.Size()})
+		buf.Reset()
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	m.sections = append(m.sections, section{data: buf.Bytes()})
+	return m, nil
+}
+
+// ContentType returns the value of the Content-Type header,
+// which includes the boundary.
+func (m *Multipart) ContentType() string {
+	return "multipart/form-data; boundary=" + m.boundary
+}
+
+func (m *Multipart) Len() int64 {
+	var size int64
+	for _, s := range m.sections {
+		size += s.length()
+	}
+	return size
+}
+
+func (m *Multipart) Open() (io.ReadCloser, error) {
+	files := []io.Closer{}
+	closeFiles := func() error {
+		var err error
+		for _, f := range files {
+			if e := f.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+		return err
+	}
+
+	readers := multiReaderAt{}
+	for _, s := range m.sections {
+		if s.file == "" {
+			readers = append(readers, io.NewSectionReader(bytes.NewReader(s.data), 0, s.length()))
+			continue
+		}
+
+		f, err := os.Open(s.file)
+		if err != nil {
+			closeFiles()
+			return nil, err
+		}
+		files = append(files, f)
+		readers = append(readers, io.NewSectionReader(f, 0, s.size))
+	}
+
+	return &multipartReader{
+		SectionReader: io.NewSectionReader(readers, 0, readers.size()),
+		close:         closeFiles,
+	}, nil
+}
+
+func (s section) length() int64 {
+	if s.file != "" {
+		return s.size
+	}
+	return int64(len(s.data))
+}
+
+type multipartReader struct {
+	*io.SectionReader
+	close func() error
+}
+
+func (r *multipartReader) Close() error {
+	return r.close()
+}
+
+// detectContentType returns the MIME type given by the extension
+// of the file, or else by sniffing its content.
+func detectContentType(path string) (string, error) {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return t, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	b := make([]byte, 512)
+	n, err := io.ReadFull(f, b)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return http.DetectContentType(b[:n]), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
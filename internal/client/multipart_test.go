@@ -0,0 +1,130 @@
+package client
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lunjon/http/internal/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTempFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestMultipart(t *testing.T) {
+	text := writeTempFile(t, "notes.txt", "some notes")
+	pdf := writeTempFile(t, "document", "%PDF-1.4 content")
+	image := writeTempFile(t, "image.bin", "not really an image")
+
+	body, err := NewMultipart([]FormPart{
+		{Name: "name", Value: "value"},
+		{Name: "text", File: text},
+		{Name: "pdf", File: pdf},
+		{Name: "image", File: image, ContentType: "image/png", Filename: `x "1".png`},
+	})
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(body.ContentType())
+	require.NoError(t, err)
+	require.Equal(t, "multipart/form-data", mediaType)
+
+	r, err := body.Open()
+	require.NoError(t, err)
+	defer r.Close()
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, body.Len(), int64(len(b)))
+
+	// The body can be read again after seeking to the start
+	seeker, ok := r.(io.ReadSeeker)
+	require.True(t, ok)
+	_, err = seeker.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	again, err := io.ReadAll(seeker)
+	require.NoError(t, err)
+	require.Equal(t, b, again)
+
+	mr := multipart.NewReader(strings.NewReader(string(b)), params["boundary"])
+	form, err := mr.ReadForm(1 << 20)
+	require.NoError(t, err)
+	require.Equal(t, []string{"value"}, form.Value["name"])
+
+	tests := []struct {
+		name        string
+		filename    string
+		contentType string
+		content     string
+	}{
+		{"text", "notes.txt", "text/plain; charset=utf-8", "some notes"},
+		{"pdf", "document", "application/pdf", "%PDF-1.4 content"},
+		{"image", `x "1".png`, "image/png", "not really an image"},
+	}
+	for _, test := range tests {
+		fh := form.File[test.name][0]
+		require.Equal(t, test.filename, fh.Filename)
+		require.Equal(t, test.contentType, fh.Header.Get("Content-Type"))
+
+		f, err := fh.Open()
+		require.NoError(t, err)
+		content, _ := io.ReadAll(f)
+		require.Equal(t, test.content, string(content))
+	}
+}
+
+func TestMultipartMissingFile(t *testing.T) {
+	_, err := NewMultipart([]FormPart{{Name: "file", File: "does-not-exist"}})
+	require.Error(t, err)
+}
+
+func TestClientSendsMultipart(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/upload", http.StatusTemporaryRedirect)
+			return
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		content, _ := io.ReadAll(file)
+		w.Write([]byte(header.Filename + ":" + string(content) + ":" + r.FormValue("name")))
+	}))
+	defer srv.Close()
+
+	file := writeTempFile(t, "upload.txt", strings.Repeat("a", 100_000))
+	body, err := NewMultipart([]FormPart{
+		{Name: "name", Value: "value"},
+		{Name: "file", File: file},
+	})
+	require.NoError(t, err)
+
+	logger := logging.NewSilentLogger()
+	client, err := NewClient(NewSettings(), logger, logger)
+	require.NoError(t, err)
+
+	u, _ := ParseURL(srv.URL+"/redirect", nil)
+	req, err := client.BuildBodyRequest("POST", u, body, http.Header{"Content-Type": {body.ContentType()}})
+	require.NoError(t, err)
+	require.Equal(t, body.Len(), req.ContentLength)
+
+	res, err := client.Send(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	b, _ := io.ReadAll(res.Body)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "upload.txt:"+strings.Repeat("a", 100_000)+":value", string(b))
+}
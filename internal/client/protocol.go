@@ -0,0 +1,37 @@
+package client
+
+import "net/http"
+
+// Protocol selects the HTTP versions used for requests.
+type Protocol string
+
+const (
+	// ProtocolDefault uses HTTP/2 if the server supports it over TLS,
+	// and HTTP/1.1 otherwise.
+	ProtocolDefault Protocol = ""
+	// ProtocolHTTP1 only uses HTTP/1.1.
+	ProtocolHTTP1 Protocol = "http1.1"
+	// ProtocolHTTP2 requires HTTP/2 over TLS. Requests without
+	// TLS use HTTP/1.1.
+	ProtocolHTTP2 Protocol = "http2"
+	// ProtocolHTTP2PriorKnowledge uses HTTP/2 also without TLS (h2c),
+	// assuming that the server supports it.
+	ProtocolHTTP2PriorKnowledge Protocol = "http2-prior-knowledge"
+)
+
+func (p Protocol) protocols() *http.Protocols {
+	protocols := new(http.Protocols)
+	switch p {
+	case ProtocolHTTP1:
+		protocols.SetHTTP1(true)
+	case ProtocolHTTP2:
+		protocols.SetHTTP2(true)
+	case ProtocolHTTP2PriorKnowledge:
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+	default:
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+	}
+	return protocols
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var proxySchemes = map[string]bool{
+	"http":    true,
+	"https":   true,
+	"socks5":  true,
+	"socks5h": true,
+}
+
+// ProxyOptions configures the proxy used for requests.
+type ProxyOptions struct {
+	// URL of the proxy. If nil, the proxy is given by the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	URL *url.URL
+	// NoProxy lists hosts that are not proxied: host names, which also
+	// match subdomains, IP addresses or CIDR ranges, with an optional
+	// port. A single * disables the proxy.
+	NoProxy []string
+	// CACert is a file with the certificates of a proxy that intercepts
+	// TLS connections, which are trusted in addition to the system roots.
+	CACert string
+}
+
+// ParseProxyURL parses the URL of a proxy. The scheme
+// defaults to http and credentials may be included.
+func ParseProxyURL(s string) (*url.URL, error) {
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if !proxySchemes[u.Scheme] {
+		return nil, fmt.Errorf("unsupported proxy scheme: %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing proxy host: %s", s)
+	}
+	return u, nil
+}
+
+// Returns the function that selects the proxy of a request.
+func (opts ProxyOptions) proxyFunc() func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		proxy := opts.URL
+		if proxy == nil {
+			var err error
+			if proxy, err = http.ProxyFromEnvironment(req); err != nil {
+				return nil, err
+			}
+		}
+
+		if proxy == nil || opts.bypass(req.URL) {
+			return nil, nil
+		}
+		tracerFromContext(req.Context()).Proxy(proxy, req.URL)
+		return proxy, nil
+	}
+}
+
+// Returns true if the URL matches an entry of NoProxy.
+func (opts ProxyOptions) bypass(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "ws": "80", "https": "443", "wss": "443"}[u.Scheme]
+	}
+	ip := net.ParseIP(host)
+
+	for _, entry := range opts.NoProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		}
+
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		name, entryPort, err := net.SplitHostPort(entry)
+		if err != nil {
+			name, entryPort = unbracket(entry), ""
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+
+		name = strings.TrimPrefix(strings.TrimPrefix(name, "*"), ".")
+		if host == name || strings.HasSuffix(host, "."+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Logs the response to a CONNECT request sent to a proxy.
+func onProxyConnectResponse(ctx context.Context, proxy *url.URL, req *http.Request, res *http.Response) error {
+	tracerFromContext(ctx).ProxyConnect(proxy, req, res)
+	return nil
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const defaultMaxRedirects = 10
+
+// RedirectOptions is the policy for following redirects.
+type RedirectOptions struct {
+	// Max is the maximum number of redirects to follow.
+	// Zero means that redirects are not followed.
+	Max int
+	// SameHostOnly refuses redirects to another host than
+	// the one of the original request.
+	SameHostOnly bool
+	// ForwardAuth keeps the Authorization header of the original
+	// request when redirected to another host. By default it
+	// is removed, unless redirected to a subdomain.
+	ForwardAuth bool
+	// Post301 and Post302 keep the method and body of a POST
+	// request when redirected with 301 and 302 respectively,
+	// instead of changing it into a GET request.
+	Post301 bool
+	Post302 bool
+}
+
+func NewRedirectOptions() RedirectOptions {
+	return RedirectOptions{
+		Max: defaultMaxRedirects,
+	}
+}
+
+func (opts RedirectOptions) WithMax(max int) RedirectOptions {
+	opts.Max = max
+	return opts
+}
+
+func (opts RedirectOptions) WithSameHostOnly(b bool) RedirectOptions {
+	opts.SameHostOnly = b
+	return opts
+}
+
+func (opts RedirectOptions) WithForwardAuth(b bool) RedirectOptions {
+	opts.ForwardAuth = b
+	return opts
+}
+
+func (opts RedirectOptions) WithPost301(b bool) RedirectOptions {
+	opts.Post301 = b
+	return opts
+}
+
+func (opts RedirectOptions) WithPost302(b bool) RedirectOptions {
+	opts.Post302 = b
+	return opts
+}
+
+// checkRedirect applies the policy to req, the upcoming request,
+// given the requests made so far in via, oldest first.
+func (opts RedirectOptions) checkRedirect(req *http.Request, via []*http.Request) error {
+	if opts.Max == 0 {
+		return http.ErrUseLastResponse
+	}
+	if len(via) > opts.Max {
+		return fmt.Errorf("stopped after %d redirects", opts.Max)
+	}
+
+	first := via[0]
+	if opts.SameHostOnly && req.URL.Host != first.URL.Host {
+		return fmt.Errorf("refusing redirect to another host: %s", req.URL.Host)
+	}
+
+	if opts.ForwardAuth && req.Header.Get("Authorization") == "" {
+		if auth := first.Header.Get("Authorization"); auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+	}
+
+	prev := via[len(via)-1]
+	if req.Response != nil && prev.Method == http.MethodPost && req.Method != prev.Method {
+		code := req.Response.StatusCode
+		if code == http.StatusMovedPermanently && opts.Post301 || code == http.StatusFound && opts.Post302 {
+			return keepMethod(req, prev)
+		}
+	}
+	return nil
+}
+
+// keepMethod sets the method and body of prev on req.
+func keepMethod(req, prev *http.Request) error {
+	req.Method = prev.Method
+	req.ContentLength = prev.ContentLength
+	req.GetBody = prev.GetBody
+	if prev.GetBody != nil {
+		body, err := prev.GetBody()
+		if err != nil {
+			return err
+		}
+		req.Body = body
+	}
+	return nil
+}
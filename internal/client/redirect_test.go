@@ -0,0 +1,144 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lunjon/http/internal/logging"
+	"github.com/stretchr/testify/require"
+)
+
+// newRedirectServer returns a server that redirects /redirect/{code}
+// to the location given by the query parameter "to", and otherwise
+// responds with the method, body and Authorization header of the request.
+func newRedirectServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/redirect/{code}", func(w http.ResponseWriter, r *http.Request) {
+		code := http.StatusFound
+		switch r.PathValue("code") {
+		case "301":
+			code = http.StatusMovedPermanently
+		case "307":
+			code = http.StatusTemporaryRedirect
+		}
+		http.Redirect(w, r, r.URL.Query().Get("to"), code)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Method + " " + string(body) + " " + r.Header.Get("Authorization")))
+	})
+	return httptest.NewServer(mux)
+}
+
+func sendRedirect(t *testing.T, opts RedirectOptions, method, url, body string) (string, []Timing, error) {
+	logger := logging.NewLogger()
+	client, err := NewClient(NewSettings().WithRedirectOptions(opts), logger, logger)
+	require.NoError(t, err)
+
+	u, err := ParseURL(url, nil)
+	require.NoError(t, err)
+
+	var b []byte
+	if body != "" {
+		b = []byte(body)
+	}
+	req, err := client.BuildRequest(method, u, b, http.Header{"Authorization": {"Bearer token"}})
+	require.NoError(t, err)
+
+	res, err := client.Send(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer res.Body.Close()
+
+	out, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(out), Hops(res), nil
+}
+
+func TestRedirectHops(t *testing.T) {
+	srv := newRedirectServer()
+	defer srv.Close()
+
+	_, hops, err := sendRedirect(t, NewRedirectOptions(), "GET", srv.URL+"/redirect/302?to=/redirect/301?to=/target", "")
+	require.NoError(t, err)
+	require.Len(t, hops, 3)
+
+	require.Equal(t, http.StatusFound, hops[0].Status)
+	require.Equal(t, "/redirect/301?to=/target", hops[0].Location)
+	require.NotZero(t, hops[0].Total)
+	require.Equal(t, http.StatusMovedPermanently, hops[1].Status)
+	require.Equal(t, "/target", hops[1].Location)
+	require.Equal(t, http.StatusOK, hops[2].Status)
+	require.Equal(t, srv.URL+"/target", hops[2].URL)
+}
+
+func TestRedirectMax(t *testing.T) {
+	srv := newRedirectServer()
+	defer srv.Close()
+
+	url := srv.URL + "/redirect/302?to=/redirect/302?to=/target"
+	_, _, err := sendRedirect(t, NewRedirectOptions().WithMax(1), "GET", url, "")
+	require.ErrorContains(t, err, "stopped after 1 redirects")
+
+	out, hops, err := sendRedirect(t, NewRedirectOptions().WithMax(0), "GET", url, "")
+	require.NoError(t, err)
+	require.Len(t, hops, 1)
+	require.Contains(t, out, "Found")
+}
+
+func TestRedirectSameHostOnly(t *testing.T) {
+	srv := newRedirectServer()
+	defer srv.Close()
+	other := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+	opts := NewRedirectOptions().WithSameHostOnly(true)
+	_, _, err := sendRedirect(t, opts, "GET", srv.URL+"/redirect/302?to="+other+"/target", "")
+	require.ErrorContains(t, err, "refusing redirect to another host")
+
+	_, _, err = sendRedirect(t, opts, "GET", srv.URL+"/redirect/302?to=/target", "")
+	require.NoError(t, err)
+}
+
+func TestRedirectForwardAuth(t *testing.T) {
+	srv := newRedirectServer()
+	defer srv.Close()
+	url := srv.URL + "/redirect/302?to=" + strings.Replace(srv.URL, "127.0.0.1", "localhost", 1) + "/target"
+
+	out, _, err := sendRedirect(t, NewRedirectOptions(), "GET", url, "")
+	require.NoError(t, err)
+	require.NotContains(t, out, "Bearer token")
+
+	out, _, err = sendRedirect(t, NewRedirectOptions().WithForwardAuth(true), "GET", url, "")
+	require.NoError(t, err)
+	require.Contains(t, out, "Bearer token")
+}
+
+func TestRedirectPost(t *testing.T) {
+	srv := newRedirectServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		opts RedirectOptions
+		code string
+		want string
+	}{
+		{"301 default", NewRedirectOptions(), "301", "GET  "},
+		{"301 keep", NewRedirectOptions().WithPost301(true), "301", "POST data "},
+		{"302 default", NewRedirectOptions().WithPost301(true), "302", "GET  "},
+		{"302 keep", NewRedirectOptions().WithPost302(true), "302", "POST data "},
+		{"307", NewRedirectOptions(), "307", "POST data "},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, _, err := sendRedirect(t, test.opts, "POST", srv.URL+"/redirect/"+test.code+"?to=/target", "data")
+			require.NoError(t, err)
+			require.True(t, strings.HasPrefix(out, test.want), out)
+		})
+	}
+}
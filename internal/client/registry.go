@@ -0,0 +1,98 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lunjon/http/internal/config"
+)
+
+// SignerFactory creates a RequestSigner from its configuration.
+type SignerFactory func(cfg config.Signer) (RequestSigner, error)
+
+// SignerRegistry holds the types of signers that
+// can be configured in the configuration file.
+type SignerRegistry struct {
+	factories map[string]SignerFactory
+}
+
+// NewSignerRegistry returns a registry with the built-in types of signers.
+func NewSignerRegistry() *SignerRegistry {
+	registry := &SignerRegistry{factories: map[string]SignerFactory{}}
+	registry.Register("basic", newBasicSignerFromConfig)
+	registry.Register("bearer", newBearerSignerFromConfig)
+	registry.Register("api-key", newAPIKeySignerFromConfig)
+	registry.Register("hmac", newHMACSignerFromConfig)
+	registry.Register("jwt", newJWTSignerFromConfig)
+	return registry
+}
+
+// Register adds a type of signer, replacing any existing with the same name.
+func (registry *SignerRegistry) Register(signerType string, factory SignerFactory) {
+	registry.factories[signerType] = factory
+}
+
+// Types returns the names of the registered types, sorted.
+func (registry *SignerRegistry) Types() []string {
+	types := []string{}
+	for t := range registry.factories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Build creates the signer with the given name in signers.
+func (registry *SignerRegistry) Build(name string, signers map[string]config.Signer) (RequestSigner, error) {
+	cfg, found := signers[name]
+	if !found {
+		return nil, fmt.Errorf("unknown signer: %s", name)
+	}
+
+	factory, found := registry.factories[cfg.Type()]
+	if !found {
+		return nil, fmt.Errorf("signer %s: unknown type %q, expected one of %v", name, cfg.Type(), registry.Types())
+	}
+
+	signer, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("signer %s: %w", name, err)
+	}
+	return signer, nil
+}
+
+func newBasicSignerFromConfig(cfg config.Signer) (RequestSigner, error) {
+	username, err := cfg.String("username", "")
+	if err != nil {
+		return nil, err
+	}
+	password, err := cfg.Secret("password")
+	if err != nil {
+		return nil, err
+	}
+	return NewBasicSigner(username, password), nil
+}
+
+func newBearerSignerFromConfig(cfg config.Signer) (RequestSigner, error) {
+	token, err := cfg.Secret("token")
+	if err != nil {
+		return nil, err
+	}
+	return NewBearerSigner(token), nil
+}
+
+func newAPIKeySignerFromConfig(cfg config.Signer) (RequestSigner, error) {
+	key, err := cfg.Secret("key")
+	if err != nil {
+		return nil, err
+	}
+	name, err := cfg.String("name", "X-API-Key")
+	if err != nil {
+		return nil, err
+	}
+	in, err := cfg.String("in", string(APIKeyInHeader))
+	if err != nil {
+		return nil, err
+	}
+	return NewAPIKeySigner(key, name, APIKeyLocation(in))
+}
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lunjon/http/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignerRegistryBuild(t *testing.T) {
+	registry := NewSignerRegistry()
+	signers := map[string]config.Signer{
+		"token":   {"type": "bearer", "token": "abc"},
+		"unknown": {"type": "other"},
+		"invalid": {"type": "hmac"},
+	}
+
+	signer, err := registry.Build("token", signers)
+	require.NoError(t, err)
+	req, _ := http.NewRequest("GET", "http://localhost", nil)
+	require.NoError(t, signer.Sign(req, nil))
+	require.Equal(t, "Bearer abc", req.Header.Get("Authorization"))
+
+	_, err = registry.Build("missing", signers)
+	require.ErrorContains(t, err, "unknown signer")
+	_, err = registry.Build("unknown", signers)
+	require.ErrorContains(t, err, "unknown type")
+	_, err = registry.Build("invalid", signers)
+	require.ErrorContains(t, err, "missing secret")
+
+	registry.Register("other", func(config.Signer) (RequestSigner, error) {
+		return DefaultSigner{}, nil
+	})
+	_, err = registry.Build("unknown", signers)
+	require.NoError(t, err)
+}
+
+func TestHMACSigner(t *testing.T) {
+	signer, err := NewSignerRegistry().Build("partner", map[string]config.Signer{
+		"partner": {"type": "hmac", "secret": "secret", "key_id": "key-1", "body_hash_header": "X-Content-Hash"},
+	})
+	require.NoError(t, err)
+
+	body := bytes.NewReader([]byte(`{"a":1}`))
+	req, _ := http.NewRequest("POST", "http://localhost/path?q=1", nil)
+	require.NoError(t, signer.Sign(req, body))
+
+	// The body is rewound after hashing
+	b, _ := io.ReadAll(body)
+	require.Equal(t, `{"a":1}`, string(b))
+
+	bodyHash := sha256.Sum256(b)
+	require.Equal(t, hex.EncodeToString(bodyHash[:]), req.Header.Get("X-Content-Hash"))
+	require.Equal(t, "key-1", req.Header.Get("X-Key-Id"))
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(strings.Join([]string{
+		"POST",
+		"/path?q=1",
+		req.Header.Get("X-Timestamp"),
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")))
+	require.Equal(t, hex.EncodeToString(mac.Sum(nil)), req.Header.Get("X-Signature"))
+}
+
+func TestJWTSignerHS256(t *testing.T) {
+	signer, err := NewSignerRegistry().Build("jwt", map[string]config.Signer{
+		"jwt": {
+			"type":     "jwt",
+			"secret":   "secret",
+			"issuer":   "http",
+			"subject":  "user",
+			"audience": "api",
+			"header":   "X-Token",
+			"claims":   map[string]any{"scope": "read"},
+			"key_id":   "kid-1",
+			"ttl":      "1m",
+		},
+	})
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "http://localhost", nil)
+	require.NoError(t, signer.Sign(req, nil))
+
+	header, claims, data, sig := decodeJWT(t, req.Header.Get("X-Token"))
+	require.Equal(t, "HS256", header["alg"])
+	require.Equal(t, "kid-1", header["kid"])
+	require.Equal(t, "http", claims["iss"])
+	require.Equal(t, "user", claims["sub"])
+	require.Equal(t, "api", claims["aud"])
+	require.Equal(t, "read", claims["scope"])
+	require.Equal(t, claims["iat"].(float64)+60, claims["exp"])
+	require.NotEmpty(t, claims["jti"])
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(data))
+	require.Equal(t, mac.Sum(nil), sig)
+}
+
+func TestJWTSignerES256(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600)
+	require.NoError(t, err)
+
+	signer, err := NewSignerRegistry().Build("jwt", map[string]config.Signer{
+		"jwt": {"type": "jwt", "key_file": keyFile, "audience": "api"},
+	})
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "http://localhost", nil)
+	require.NoError(t, signer.Sign(req, nil))
+
+	token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	require.True(t, found)
+
+	header, claims, data, sig := decodeJWT(t, token)
+	require.Equal(t, "ES256", header["alg"])
+	require.Equal(t, "api", claims["aud"])
+
+	digest := sha256.Sum256([]byte(data))
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	require.True(t, ecdsa.Verify(&key.PublicKey, digest[:], r, s))
+}
+
+func TestJWTSignerAlgorithmMismatch(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600)
+	require.NoError(t, err)
+
+	_, err = NewSignerRegistry().Build("jwt", map[string]config.Signer{
+		"jwt": {"type": "jwt", "key_file": keyFile, "algorithm": "RS256"},
+	})
+	require.ErrorContains(t, err, "algorithm RS256 does not match the key")
+
+	_, err = NewSignerRegistry().Build("jwt", map[string]config.Signer{
+		"jwt": {"type": "jwt", "key_file": keyFile, "algorithm": "ES256"},
+	})
+	require.NoError(t, err)
+}
+
+func decodeJWT(t *testing.T, token string) (header, claims map[string]any, data string, sig []byte) {
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+
+	for i, v := range []*map[string]any{&header, &claims} {
+		b, err := base64.RawURLEncoding.DecodeString(parts[i])
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(b, v))
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	return header, claims, parts[0] + "." + parts[1], sig
+}
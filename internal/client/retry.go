@@ -0,0 +1,147 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	RetryOnConnRefused = "connrefused"
+	RetryOnTimeout     = "timeout"
+)
+
+// MaxRetryDelay is the maximum delay before a retry, also when
+// given by a Retry-After header.
+const MaxRetryDelay = 2 * time.Minute
+
+var DefaultRetryOn = []string{"429", "502", "503", "504", RetryOnConnRefused, RetryOnTimeout}
+
+// RetryOptions configures how failed requests are retried.
+type RetryOptions struct {
+	// Max is the maximum number of retries. Zero disables retries.
+	Max int
+	// Delay before the first retry, which is doubled for each retry.
+	Delay time.Duration
+	// MaxTime limits the total time spent on all attempts. Zero means no limit.
+	MaxTime time.Duration
+
+	statuses    map[int]bool
+	connRefused bool
+	timeout     bool
+}
+
+func NewRetryOptions() RetryOptions {
+	opts := RetryOptions{Delay: time.Second}
+	opts, _ = opts.WithRetryOn(DefaultRetryOn)
+	return opts
+}
+
+func (opts RetryOptions) WithMax(max int) RetryOptions {
+	opts.Max = max
+	return opts
+}
+
+func (opts RetryOptions) WithDelay(delay time.Duration) RetryOptions {
+	opts.Delay = delay
+	return opts
+}
+
+func (opts RetryOptions) WithMaxTime(t time.Duration) RetryOptions {
+	opts.MaxTime = t
+	return opts
+}
+
+// WithRetryOn sets the conditions to retry on. Each value is either
+// an HTTP status code or one of connrefused and timeout.
+func (opts RetryOptions) WithRetryOn(values []string) (RetryOptions, error) {
+	opts.statuses = map[int]bool{}
+	opts.connRefused = false
+	opts.timeout = false
+
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		switch value {
+		case RetryOnConnRefused:
+			opts.connRefused = true
+		case RetryOnTimeout:
+			opts.timeout = true
+		default:
+			status, err := strconv.Atoi(value)
+			if err != nil || status < 100 || status > 599 {
+				return opts, fmt.Errorf("invalid retry condition: %s", value)
+			}
+			opts.statuses[status] = true
+		}
+	}
+	return opts, nil
+}
+
+// Returns a description of why the attempt should be retried,
+// or an empty string if it should not.
+func (opts RetryOptions) shouldRetry(res *http.Response, err error) string {
+	if err != nil {
+		var netErr net.Error
+		if opts.connRefused && errors.Is(err, syscall.ECONNREFUSED) {
+			return "connection refused"
+		}
+		if opts.timeout && (errors.Is(err, os.ErrDeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())) {
+			return "timeout"
+		}
+		return ""
+	}
+
+	if opts.statuses[res.StatusCode] {
+		return res.Status
+	}
+	return ""
+}
+
+// Returns the delay before the given retry (starting at 1).
+// A Retry-After header in the response takes precedence,
+// otherwise exponential backoff with jitter is used.
+// The delay is limited to MaxRetryDelay, or Delay if greater.
+func (opts RetryOptions) backoff(retry int, res *http.Response) time.Duration {
+	limit := max(MaxRetryDelay, opts.Delay)
+	if res != nil {
+		if delay, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+			return min(delay, limit)
+		}
+	}
+
+	delay := opts.Delay
+	for i := 1; i < retry && delay > 0 && delay < limit; i++ {
+		delay *= 2
+	}
+	if opts.Delay > 0 {
+		delay += rand.N(opts.Delay)
+	}
+	return min(delay, limit)
+}
+
+// Parses the value of a Retry-After header, which is either
+// a number of seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		seconds = min(seconds, math.MaxInt64/int(time.Second))
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
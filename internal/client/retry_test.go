@@ -0,0 +1,147 @@
+package client
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lunjon/http/internal/logging"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns a server that responds with 503 until it has been called failures times.
+func setupRetryServer(t *testing.T, failures int, bodies *[]string) *httptest.Server {
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		*bodies = append(*bodies, string(b))
+
+		count++
+		if count <= failures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		failures int
+		retries  int
+		status   int
+	}{
+		{0, 0, http.StatusOK},
+		{1, 0, http.StatusServiceUnavailable},
+		{1, 1, http.StatusOK},
+		{2, 3, http.StatusOK},
+		{3, 2, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			bodies := []string{}
+			server := setupRetryServer(t, tt.failures, &bodies)
+
+			logger := logging.NewSilentLogger()
+			settings := NewSettings().WithRetryOptions(NewRetryOptions().WithMax(tt.retries))
+			client, err := NewClient(settings, logger, logger)
+			require.NoError(t, err)
+
+			url, _ := ParseURL(server.URL, nil)
+			req, err := client.BuildRequest("POST", url, []byte("body"), nil)
+			require.NoError(t, err)
+
+			res, err := client.Send(req)
+			require.NoError(t, err)
+			require.Equal(t, tt.status, res.StatusCode)
+			require.Len(t, bodies, min(tt.failures, tt.retries)+1)
+			for _, body := range bodies {
+				require.Equal(t, "body", body)
+			}
+		})
+	}
+}
+
+func TestRetryConnectionRefused(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	logger := logging.NewSilentLogger()
+	retry := NewRetryOptions().WithMax(2).WithDelay(time.Millisecond)
+	client, _ := NewClient(NewSettings().WithRetryOptions(retry), logger, logger)
+
+	url, _ := ParseURL(server.URL, nil)
+	req, err := client.BuildRequest("GET", url, nil, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = client.Send(req)
+	require.Error(t, err)
+	require.GreaterOrEqual(t, time.Since(start), time.Millisecond*3)
+}
+
+func TestRetryOn(t *testing.T) {
+	opts, err := NewRetryOptions().WithRetryOn([]string{"500", "timeout"})
+	require.NoError(t, err)
+	require.True(t, opts.timeout)
+	require.False(t, opts.connRefused)
+	require.NotEmpty(t, opts.shouldRetry(&http.Response{StatusCode: 500, Status: "500"}, nil))
+	require.Empty(t, opts.shouldRetry(&http.Response{StatusCode: 503}, nil))
+
+	_, err = NewRetryOptions().WithRetryOn([]string{"nope"})
+	require.Error(t, err)
+	_, err = NewRetryOptions().WithRetryOn([]string{"99"})
+	require.Error(t, err)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value    string
+		ok       bool
+		expected time.Duration
+	}{
+		{"", false, 0},
+		{"abc", false, 0},
+		{"0", true, 0},
+		{"3", true, time.Second * 3},
+		{"99999999999999", true, time.Duration(math.MaxInt64 / int(time.Second) * int(time.Second))},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			delay, ok := parseRetryAfter(tt.value)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.expected, delay)
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	opts := NewRetryOptions().WithDelay(time.Second)
+
+	delay := opts.backoff(1, nil)
+	require.GreaterOrEqual(t, delay, time.Second)
+	require.Less(t, delay, 2*time.Second)
+
+	for _, retry := range []int{10, 64, 100, 1000000} {
+		delay := opts.backoff(retry, nil)
+		require.Positive(t, delay)
+		require.LessOrEqual(t, delay, MaxRetryDelay)
+	}
+
+	// The limit is the delay if it is greater than the maximum
+	opts = opts.WithDelay(5 * time.Minute)
+	require.Equal(t, 5*time.Minute, opts.backoff(100, nil))
+
+	res := &http.Response{Header: http.Header{"Retry-After": {"86400"}}}
+	require.Equal(t, 5*time.Minute, opts.backoff(1, res))
+	require.Equal(t, MaxRetryDelay, NewRetryOptions().backoff(1, res))
+}
@@ -1,8 +1,14 @@
 package client
 
 import (
+	"crypto/tls"
+	"net"
 	"net/http"
+	"net/http/cookiejar"
+	"os"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 type checkRedirectFunc func(*http.Request, []*http.Request) error
@@ -11,13 +17,31 @@ type Settings struct {
 	Timeout         time.Duration
 	TLS             TLSOptions
 	FollowRedirects bool
+	Redirect        RedirectOptions
+	Retry           RetryOptions
+	Dial            DialOptions
+	Proxy           ProxyOptions
+	Protocol        Protocol
+	// Compressed requests a response compressed with
+	// any of the supported content encodings.
+	Compressed bool
+	// ExpectContinueTimeout is how long to wait for a 100 Continue
+	// response before sending the body, if the request has the
+	// header "Expect: 100-continue".
+	ExpectContinueTimeout time.Duration
+	// Jar is used for cookies. If nil, an in-memory jar is used.
+	Jar http.CookieJar
 }
 
 func NewSettings() Settings {
 	return Settings{
 		Timeout:         time.Second * 30,
 		FollowRedirects: true,
+		Redirect:        NewRedirectOptions(),
 		TLS:             NewTLSOptions(),
+		Retry:           NewRetryOptions(),
+		// Same as curl
+		ExpectContinueTimeout: time.Second,
 	}
 }
 
@@ -26,6 +50,52 @@ func (s Settings) WithTLSOptions(opts TLSOptions) Settings {
 	return s
 }
 
+func (s Settings) WithRedirectOptions(opts RedirectOptions) Settings {
+	s.Redirect = opts
+	return s
+}
+
+func (s Settings) WithRetryOptions(opts RetryOptions) Settings {
+	s.Retry = opts
+	return s
+}
+
+func (s Settings) WithDialOptions(opts DialOptions) Settings {
+	s.Dial = opts
+	return s
+}
+
+func (s Settings) WithProxyOptions(opts ProxyOptions) Settings {
+	s.Proxy = opts
+	return s
+}
+
+func (s Settings) WithProtocol(p Protocol) Settings {
+	s.Protocol = p
+	return s
+}
+
+func (s Settings) WithCompressed(b bool) Settings {
+	s.Compressed = b
+	return s
+}
+
+func (s Settings) WithExpectContinueTimeout(t time.Duration) Settings {
+	s.ExpectContinueTimeout = t
+	return s
+}
+
+// WithUnixSocket makes all connections to the Unix domain socket at path.
+func (s Settings) WithUnixSocket(path string) Settings {
+	s.Dial.UnixSocket = path
+	return s
+}
+
+func (s Settings) WithCookieJar(jar http.CookieJar) Settings {
+	s.Jar = jar
+	return s
+}
+
 func (s Settings) WithTimeout(t time.Duration) Settings {
 	s.Timeout = t
 	return s
@@ -37,12 +107,17 @@ func (s Settings) WithNoFollowRedirects(b bool) Settings {
 }
 
 func (s Settings) BuildHTTPClient() (*http.Client, error) {
-	tlsConfig, err := s.TLS.getTLSConfig()
+	tlsConfig, err := s.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	jar, err := s.cookieJar()
 	if err != nil {
 		return nil, err
 	}
 
-	var redirect checkRedirectFunc
+	var redirect checkRedirectFunc = s.Redirect.checkRedirect
 	if !s.FollowRedirects {
 		redirect = func(*http.Request, []*http.Request) error {
 			return http.ErrUseLastResponse
@@ -52,9 +127,86 @@ func (s Settings) BuildHTTPClient() (*http.Client, error) {
 	return &http.Client{
 		Timeout:       s.Timeout,
 		CheckRedirect: redirect,
-		Transport: &http.Transport{
-			Proxy:           http.ProxyFromEnvironment,
-			TLSClientConfig: &tlsConfig,
+		Jar:           jar,
+		Transport: &timingTransport{
+			next: &http.Transport{
+				Proxy:                  s.Proxy.proxyFunc(),
+				OnProxyConnectResponse: onProxyConnectResponse,
+				DialContext:            s.Dial.dialContext(newDialer()),
+				TLSClientConfig:        tlsConfig,
+				Protocols:              s.Protocol.protocols(),
+				ExpectContinueTimeout:  s.ExpectContinueTimeout,
+				// Responses are decoded by the client instead,
+				// which supports more content encodings.
+				DisableCompression: true,
+			},
 		},
 	}, nil
 }
+
+// BuildWebSocketDialer returns a dialer that uses the same
+// TLS and proxy configuration as the HTTP client.
+func (s Settings) BuildWebSocketDialer() (*websocket.Dialer, error) {
+	tlsConfig, err := s.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	jar, err := s.cookieJar()
+	if err != nil {
+		return nil, err
+	}
+
+	return &websocket.Dialer{
+		Jar:              jar,
+		Proxy:            s.Proxy.proxyFunc(),
+		NetDialContext:   s.Dial.dialContext(newDialer()),
+		TLSClientConfig:  tlsConfig,
+		HandshakeTimeout: s.Timeout,
+	}, nil
+}
+
+// Returns the TLS configuration, trusting the CA of the proxy if given.
+func (s Settings) tlsConfig() (*tls.Config, error) {
+	opts := s.TLS
+	if s.Proxy.CACert != "" {
+		opts = opts.WithCACerts(s.Proxy.CACert)
+	}
+
+	tlsConfig, err := opts.getTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &tlsConfig, nil
+}
+
+// Returns the settings with the key log file of the TLS options opened,
+// if any, so that it is opened once for all connections. The file is
+// nil if not opened, and must otherwise be closed by the caller.
+func (s Settings) openKeyLog() (Settings, *os.File, error) {
+	if s.TLS.KeyLogFile == "" {
+		return s, nil, nil
+	}
+
+	f, err := os.OpenFile(s.TLS.KeyLogFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return s, nil, err
+	}
+	s.TLS.keyLog = f
+	return s, f, nil
+}
+
+// Returns a dialer with the same settings as the default transport.
+func newDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   time.Second * 30,
+		KeepAlive: time.Second * 30,
+	}
+}
+
+func (s Settings) cookieJar() (http.CookieJar, error) {
+	if s.Jar != nil {
+		return s.Jar, nil
+	}
+	return cookiejar.New(nil)
+}
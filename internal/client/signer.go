@@ -5,34 +5,95 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/aws/signer/v4"
 )
 
-// RequestSigner is used to sign request using AWS sig v4.
+const DefaultAWSService = "execute-api"
+
+// RequestSigner is used to sign, or authenticate, requests.
 type RequestSigner interface {
 	Sign(r *http.Request, body io.ReadSeeker) error
 }
 
+// AWSigner signs requests using AWS signature V4.
 type AWSigner struct {
-	region string
-	sgn    *v4.Signer
+	service string
+	region  string
+	presign time.Duration
+	sgn     *v4.Signer
 }
 
-func NewAWSigner(sgn *v4.Signer, region string) *AWSigner {
+func NewAWSigner(sgn *v4.Signer, service, region string) *AWSigner {
 	return &AWSigner{
-		sgn:    sgn,
-		region: region,
+		sgn:     sgn,
+		service: service,
+		region:  region,
 	}
 }
 
+// WithPresign makes the signer add the signature to the query
+// of the URL, which is then valid for the given duration.
+func (s *AWSigner) WithPresign(expires time.Duration) *AWSigner {
+	s.presign = expires
+	return s
+}
+
 func (s *AWSigner) Sign(r *http.Request, body io.ReadSeeker) error {
-	creds := credentials.NewCredentials(&credentials.EnvProvider{})
-	signer := v4.NewSigner(creds)
-	_, err := signer.Sign(r, body, "execute-api", s.region, time.Now())
+	var err error
+	if s.presign > 0 {
+		_, err = s.sgn.Presign(r, body, s.service, s.region, s.presign, time.Now())
+	} else {
+		_, err = s.sgn.Sign(r, body, s.service, s.region, time.Now())
+	}
 	return err
 }
 
+// AWSOptions configures how AWS credentials and region are resolved.
+type AWSOptions struct {
+	// Profile in the shared config and credentials files.
+	// If empty, AWS_PROFILE or the default profile is used.
+	Profile string
+	// Region overrides the region resolved from the environment and profile.
+	Region string
+	// RoleARN of a role to assume using the resolved credentials.
+	RoleARN string
+	// STSEndpoint is used when assuming a role, if set.
+	STSEndpoint string
+}
+
+// LoadAWSCredentials resolves credentials and region in the same way as the
+// AWS CLI: from environment variables, the shared config and credentials files
+// (including session tokens and roles of profiles) and instance metadata.
+func LoadAWSCredentials(opts AWSOptions) (*credentials.Credentials, string, error) {
+	sessionOpts := session.Options{
+		Profile:                 opts.Profile,
+		SharedConfigState:       session.SharedConfigEnable,
+		AssumeRoleTokenProvider: stscreds.StdinTokenProvider,
+	}
+	if opts.Region != "" {
+		sessionOpts.Config.Region = aws.String(opts.Region)
+	}
+
+	sess, err := session.NewSessionWithOptions(sessionOpts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	creds := sess.Config.Credentials
+	if opts.RoleARN != "" {
+		stsConfig := &aws.Config{}
+		if opts.STSEndpoint != "" {
+			stsConfig.Endpoint = aws.String(opts.STSEndpoint)
+		}
+		creds = stscreds.NewCredentials(sess.Copy(stsConfig), opts.RoleARN)
+	}
+	return creds, aws.StringValue(sess.Config.Region), nil
+}
+
 type DefaultSigner struct{}
 
 func (s DefaultSigner) Sign(r *http.Request, body io.ReadSeeker) error {
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func newStaticSigner() *v4.Signer {
+	creds := credentials.NewStaticCredentials("AKIAEXAMPLE", "secret", "token")
+	return v4.NewSigner(creds)
+}
+
+func TestAWSigner(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://bucket.s3.amazonaws.com/key", nil)
+	signer := NewAWSigner(newStaticSigner(), "s3", "eu-north-1")
+
+	err := signer.Sign(req, bytes.NewReader(nil))
+	require.NoError(t, err)
+
+	auth := req.Header.Get("Authorization")
+	require.Contains(t, auth, "/eu-north-1/s3/aws4_request")
+	require.Equal(t, "token", req.Header.Get("X-Amz-Security-Token"))
+}
+
+func TestAWSignerPresign(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://bucket.s3.amazonaws.com/key", nil)
+	signer := NewAWSigner(newStaticSigner(), "s3", "eu-north-1").WithPresign(time.Minute)
+
+	err := signer.Sign(req, bytes.NewReader(nil))
+	require.NoError(t, err)
+
+	query := req.URL.Query()
+	require.NotEmpty(t, query.Get("X-Amz-Signature"))
+	require.Equal(t, "60", query.Get("X-Amz-Expires"))
+	require.Empty(t, req.Header.Get("Authorization"))
+}
+
+func TestLoadAWSCredentialsProfile(t *testing.T) {
+	for _, name := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_REGION", "AWS_DEFAULT_REGION", "AWS_PROFILE"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+
+	dir := t.TempDir()
+	credentialsFile := path.Join(dir, "credentials")
+	configFile := path.Join(dir, "config")
+	os.WriteFile(credentialsFile, []byte(strings.Join([]string{
+		"[test]",
+		"aws_access_key_id = AKIATEST",
+		"aws_secret_access_key = secret",
+		"aws_session_token = session",
+	}, "\n")), 0600)
+	os.WriteFile(configFile, []byte("[profile test]\nregion = ap-south-1\n"), 0600)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+
+	creds, region, err := LoadAWSCredentials(AWSOptions{Profile: "test"})
+	require.NoError(t, err)
+	require.Equal(t, "ap-south-1", region)
+
+	value, err := creds.Get()
+	require.NoError(t, err)
+	require.Equal(t, "AKIATEST", value.AccessKeyID)
+	require.Equal(t, "session", value.SessionToken)
+
+	// Region is overridden
+	_, region, err = LoadAWSCredentials(AWSOptions{Profile: "test", Region: "us-east-1"})
+	require.NoError(t, err)
+	require.Equal(t, "us-east-1", region)
+}
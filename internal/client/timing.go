@@ -0,0 +1,174 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+// Timing is the duration of each phase of a single hop of a request,
+// i.e. one request/response exchange. A request that follows redirects
+// or is retried has one hop per exchange.
+//
+// Phases that did not occur are zero, e.g. DNS, Connect and TLS
+// when the connection was reused.
+type Timing struct {
+	Method string
+	URL    string
+	Status int
+	// Location is the Location header of the response, if any.
+	Location string
+	// Reused is true if the hop was sent on a previously used connection.
+	Reused bool
+
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// Send is the time from obtaining the connection until
+	// the request was written.
+	Send time.Duration
+	// TTFB is the time from the request was written
+	// until the first byte of the response was read.
+	TTFB time.Duration
+	// Transfer is the time from the first byte of the response
+	// until the body was read to completion or closed.
+	Transfer time.Duration
+	Total    time.Duration
+}
+
+func (t Timing) MarshalJSON() ([]byte, error) {
+	ms := func(d time.Duration) float64 {
+		return float64(d.Microseconds()) / 1000
+	}
+
+	return json.Marshal(struct {
+		Method   string  `json:"method"`
+		URL      string  `json:"url"`
+		Status   int     `json:"status"`
+		Location string  `json:"location,omitempty"`
+		Reused   bool    `json:"reused_connection"`
+		DNS      float64 `json:"dns_ms"`
+		Connect  float64 `json:"connect_ms"`
+		TLS      float64 `json:"tls_ms"`
+		Send     float64 `json:"send_ms"`
+		TTFB     float64 `json:"ttfb_ms"`
+		Transfer float64 `json:"transfer_ms"`
+		Total    float64 `json:"total_ms"`
+	}{
+		Method:   t.Method,
+		URL:      t.URL,
+		Status:   t.Status,
+		Location: t.Location,
+		Reused:   t.Reused,
+		DNS:      ms(t.DNS),
+		Connect:  ms(t.Connect),
+		TLS:      ms(t.TLS),
+		Send:     ms(t.Send),
+		TTFB:     ms(t.TTFB),
+		Transfer: ms(t.Transfer),
+		Total:    ms(t.Total),
+	})
+}
+
+// Hops returns the timing of each redirect that led to res, in the
+// order they were followed, and last the timing of res itself.
+// Only the method, URL, status and location are set for
+// responses of requests that were not sent by a Client.
+func Hops(res *http.Response) []Timing {
+	var hops []Timing
+	for r := res; r != nil && r.Request != nil; r = r.Request.Response {
+		timing, found := tracerFromContext(r.Request.Context()).timingOf(r)
+		if !found {
+			timing = Timing{
+				Method:   r.Request.Method,
+				URL:      r.Request.URL.String(),
+				Status:   r.StatusCode,
+				Location: r.Header.Get("Location"),
+			}
+		}
+		hops = append(hops, timing)
+	}
+
+	slices.Reverse(hops)
+	return hops
+}
+
+// FormatTimings returns the timings as a table with one row per hop.
+func FormatTimings(timings []Timing) string {
+	d := func(d time.Duration) string {
+		if d == 0 {
+			return "-"
+		}
+		return d.Round(time.Microsecond).String()
+	}
+
+	buf := bytes.NewBuffer(nil)
+	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tRequest\tStatus\tDNS\tConnect\tTLS\tSend\tTTFB\tTransfer\tTotal")
+	for i, t := range timings {
+		status := "-"
+		if t.Status != 0 {
+			status = fmt.Sprint(t.Status)
+		}
+		if t.Reused {
+			status += " (reused)"
+		}
+
+		fmt.Fprintf(w, "%d\t%s %s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			i+1, t.Method, t.URL, status,
+			d(t.DNS), d(t.Connect), d(t.TLS), d(t.Send), d(t.TTFB), d(t.Transfer), d(t.Total))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// timingTransport starts a new hop in the tracer of the request
+// context for every round trip, which makes redirects and retries
+// get separate timings.
+type timingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *timingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tracer := tracerFromContext(req.Context())
+	if tracer == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	hop := tracer.startHop(req)
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		tracer.finishHop(hop)
+		return nil, err
+	}
+
+	tracer.gotResponse(hop, res)
+	res.Body = &timingBody{ReadCloser: res.Body, tracer: tracer, hop: hop}
+	return res, nil
+}
+
+// timingBody finishes the hop when the body has been
+// read to completion or closed, whichever comes first.
+type timingBody struct {
+	io.ReadCloser
+	tracer *Tracer
+	hop    *hop
+}
+
+func (b *timingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.tracer.finishHop(b.hop)
+	}
+	return n, err
+}
+
+func (b *timingBody) Close() error {
+	b.tracer.finishHop(b.hop)
+	return b.ReadCloser.Close()
+}
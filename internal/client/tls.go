@@ -1,18 +1,49 @@
 package client
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/lunjon/http/internal/types"
 	"golang.org/x/crypto/pkcs12"
 )
 
+const publicKeyPinPrefix = "sha256//"
+
 type TLSOptions struct {
 	Cert               types.Option[certificate]
 	MinVersion         uint16
 	MaxVersion         uint16
 	SkipVerifyInsecure bool
+	// CACerts are files with PEM encoded certificates
+	// that are trusted in addition to the system roots.
+	CACerts []string
+	// CAPath is a directory with files of PEM encoded certificates
+	// that are trusted in addition to the system roots.
+	CAPath string
+	// Pins are SHA-256 hashes of public keys, of which the
+	// server certificate must match one if any is given.
+	Pins [][]byte
+	// KeyLogFile is a file that TLS secrets are appended to, in the
+	// NSS key log format, allowing e.g. Wireshark to decrypt connections.
+	KeyLogFile string
+	// keyLog is the opened KeyLogFile, which is shared by
+	// all connections of a client and closed with it.
+	keyLog io.Writer
+	// ServerName is used for SNI and verification instead of the host.
+	ServerName string
+	// ALPN is the protocols to offer during the handshake.
+	ALPN []string
 }
 
 func NewTLSOptions() TLSOptions {
@@ -30,6 +61,71 @@ func (tlsOptions TLSOptions) WithVersions(min, max uint16) TLSOptions {
 	return tlsOptions
 }
 
+func (tlsOptions TLSOptions) WithSkipVerifyInsecure(b bool) TLSOptions {
+	tlsOptions.SkipVerifyInsecure = b
+	return tlsOptions
+}
+
+// WithCACerts adds files with certificates to trust.
+func (tlsOptions TLSOptions) WithCACerts(files ...string) TLSOptions {
+	tlsOptions.CACerts = append(slices.Clone(tlsOptions.CACerts), files...)
+	return tlsOptions
+}
+
+func (tlsOptions TLSOptions) WithCAPath(dir string) TLSOptions {
+	tlsOptions.CAPath = dir
+	return tlsOptions
+}
+
+func (tlsOptions TLSOptions) WithPins(pins [][]byte) TLSOptions {
+	tlsOptions.Pins = pins
+	return tlsOptions
+}
+
+func (tlsOptions TLSOptions) WithKeyLogFile(filename string) TLSOptions {
+	tlsOptions.KeyLogFile = filename
+	return tlsOptions
+}
+
+func (tlsOptions TLSOptions) WithServerName(name string) TLSOptions {
+	tlsOptions.ServerName = name
+	return tlsOptions
+}
+
+func (tlsOptions TLSOptions) WithALPN(protocols []string) TLSOptions {
+	tlsOptions.ALPN = protocols
+	return tlsOptions
+}
+
+// ParsePublicKeyPins parses public key pins in the format
+// sha256//BASE64[;sha256//BASE64]..., as used by curl.
+func ParsePublicKeyPins(s string) ([][]byte, error) {
+	pins := [][]byte{}
+	for _, pin := range strings.Split(s, ";") {
+		pin = strings.TrimSpace(pin)
+		if pin == "" {
+			continue
+		}
+
+		encoded, found := strings.CutPrefix(pin, publicKeyPinPrefix)
+		if !found {
+			return nil, fmt.Errorf("invalid public key pin, expected %sBASE64: %s", publicKeyPinPrefix, pin)
+		}
+		hash, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("invalid public key pin: %s", pin)
+		}
+		pins = append(pins, hash)
+	}
+	return pins, nil
+}
+
+// PublicKeyPin returns the pin of the public key of the certificate.
+func PublicKeyPin(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return publicKeyPinPrefix + base64.StdEncoding.EncodeToString(hash[:])
+}
+
 func (tlsOptions TLSOptions) WithX509Cert(certfile, keyfile string) TLSOptions {
 	tlsOptions.Cert = tlsOptions.Cert.Set(x509Cert{
 		certfile: certfile,
@@ -57,14 +153,98 @@ func (tlsOptions TLSOptions) getTLSConfig() (tls.Config, error) {
 		certs = append(certs, crt)
 	}
 
+	rootCAs, err := tlsOptions.rootCAs()
+	if err != nil {
+		return tls.Config{}, err
+	}
+
+	var verify func(tls.ConnectionState) error
+	if len(tlsOptions.Pins) > 0 {
+		verify = tlsOptions.verifyPins
+	}
+
 	return tls.Config{
 		Certificates:       certs,
+		RootCAs:            rootCAs,
 		InsecureSkipVerify: tlsOptions.SkipVerifyInsecure,
+		VerifyConnection:   verify,
 		MinVersion:         tlsOptions.MinVersion,
 		MaxVersion:         tlsOptions.MaxVersion,
+		KeyLogWriter:       tlsOptions.keyLog,
+		ServerName:         tlsOptions.ServerName,
+		NextProtos:         tlsOptions.ALPN,
 	}, nil
 }
 
+// Returns the pool of trusted certificates, or nil
+// if only the system roots should be used.
+func (tlsOptions TLSOptions) rootCAs() (*x509.CertPool, error) {
+	if len(tlsOptions.CACerts) == 0 && tlsOptions.CAPath == "" {
+		return nil, nil
+	}
+
+	pool, err := loadCertPool(tlsOptions.CACerts...)
+	if err != nil || tlsOptions.CAPath == "" {
+		return pool, err
+	}
+
+	// Files in the directory that are not certificates are ignored
+	entries, err := os.ReadDir(tlsOptions.CAPath)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(tlsOptions.CAPath, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		found = pool.AppendCertsFromPEM(b) || found
+	}
+	if !found {
+		return nil, fmt.Errorf("no certificates found in %s", tlsOptions.CAPath)
+	}
+	return pool, nil
+}
+
+// Verifies that the public key of the server certificate matches a pin.
+func (tlsOptions TLSOptions) verifyPins(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("no server certificate to verify public key pin")
+	}
+
+	hash := sha256.Sum256(state.PeerCertificates[0].RawSubjectPublicKeyInfo)
+	for _, pin := range tlsOptions.Pins {
+		if bytes.Equal(pin, hash[:]) {
+			return nil
+		}
+	}
+	return fmt.Errorf("public key of server certificate does not match any pin: %s", PublicKeyPin(state.PeerCertificates[0]))
+}
+
+// Returns the system certificate pool with the
+// certificates in the PEM encoded files added.
+func loadCertPool(files ...string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in %s", file)
+		}
+	}
+	return pool, nil
+}
+
 type certificate interface {
 	Load() (tls.Certificate, error)
 }
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lunjon/http/internal/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePublicKeyPins(t *testing.T) {
+	pin := "sha256//47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+	pins, err := ParsePublicKeyPins(pin + ";" + pin)
+	require.NoError(t, err)
+	require.Len(t, pins, 2)
+
+	for _, s := range []string{"47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=", "sha256//abc", "sha1//47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="} {
+		_, err := ParsePublicKeyPins(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestTLSOptionsVerification(t *testing.T) {
+	target := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+
+	caPath := t.TempDir()
+	caFile := filepath.Join(caPath, "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: target.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, caPEM, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(caPath, "README"), []byte("not a certificate"), 0600))
+
+	pins, err := ParsePublicKeyPins(PublicKeyPin(target.Certificate()))
+	require.NoError(t, err)
+	otherPins, err := ParsePublicKeyPins("sha256//47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		opts    TLSOptions
+		wantErr bool
+	}{
+		{"system roots", NewTLSOptions(), true},
+		{"ca cert", NewTLSOptions().WithCACerts(caFile), false},
+		{"ca path", NewTLSOptions().WithCAPath(caPath), false},
+		{"insecure", NewTLSOptions().WithSkipVerifyInsecure(true), false},
+		{"pin", NewTLSOptions().WithCACerts(caFile).WithPins(pins), false},
+		{"pin mismatch", NewTLSOptions().WithCACerts(caFile).WithPins(otherPins), true},
+		{"pin mismatch insecure", NewTLSOptions().WithSkipVerifyInsecure(true).WithPins(otherPins), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := logging.NewLogger()
+			client, err := NewClient(NewSettings().WithTLSOptions(tt.opts), logger, logger)
+			require.NoError(t, err)
+
+			u, _ := url.Parse(target.URL)
+			req, err := client.BuildRequest(http.MethodGet, u, nil, nil)
+			require.NoError(t, err)
+
+			_, err = client.Send(req)
+			require.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+
+	_, err = NewTLSOptions().WithCAPath(t.TempDir()).getTLSConfig()
+	require.Error(t, err)
+}
+
+func TestTLSOptionsHandshake(t *testing.T) {
+	var serverName string
+	target := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	target.TLS = &tls.Config{
+		NextProtos: []string{"h2", "http/1.1"},
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverName = hello.ServerName
+			return nil, nil
+		},
+	}
+	target.StartTLS()
+	defer target.Close()
+
+	keyLogFile := filepath.Join(t.TempDir(), "keys.log")
+	opts := NewTLSOptions().
+		WithKeyLogFile(keyLogFile).
+		WithServerName("api.example").
+		WithALPN([]string{"http/1.1"})
+
+	host, port, err := net.SplitHostPort(target.Listener.Addr().String())
+	require.NoError(t, err)
+
+	report, err := NewSettings().WithTLSOptions(opts).InspectTLS(t.Context(), host, port, false)
+	require.NoError(t, err)
+	require.Equal(t, "api.example", serverName)
+	require.Equal(t, "http/1.1", report.ALPN)
+	require.Contains(t, report.VerificationError, "api.example")
+
+	b, err := os.ReadFile(keyLogFile)
+	require.NoError(t, err)
+	require.Contains(t, string(b), "CLIENT_TRAFFIC_SECRET_0")
+
+	// The file is opened once by the client, for all connections
+	logger := logging.NewSilentLogger()
+	client, err := NewClient(NewSettings().WithTLSOptions(opts), logger, logger)
+	require.NoError(t, err)
+	require.NotNil(t, client.keyLog)
+
+	dialer, err := client.settings.BuildWebSocketDialer()
+	require.NoError(t, err)
+	require.Same(t, client.keyLog, dialer.TLSClientConfig.KeyLogWriter)
+	require.NoError(t, client.Close())
+}
@@ -1,28 +1,42 @@
 package client
 
 import (
-	"bytes"
+	"context"
 	"crypto/tls"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
-	"text/tabwriter"
+	"sync"
 	"time"
 
 	"net/http/httptrace"
 )
 
+// Tracer logs the events of requests and records
+// the timing of each hop.
 type Tracer struct {
-	currentRequest *http.Request
-	logger         *log.Logger
+	logger *log.Logger
 
-	dnsStart        time.Time
-	dnsDuration     time.Duration
-	tlsStart        time.Time
-	tlsDuration     time.Duration
-	connectStart    time.Time
-	connectDuration time.Duration
+	mu        sync.Mutex
+	hops      []*hop
+	current   *hop
+	responses map[*http.Response]*hop
+}
+
+// hop holds the timestamps of a single request/response exchange.
+type hop struct {
+	timing Timing
+	done   bool
+
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	gotConn      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
 }
 
 func newTracer(logger *log.Logger) *Tracer {
@@ -31,34 +45,141 @@ func newTracer(logger *log.Logger) *Tracer {
 	}
 }
 
-func (t *Tracer) Report(total time.Duration) {
-	buf := bytes.NewBuffer(nil)
-	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', tabwriter.TabIndent)
-	fmt.Fprintf(w, "Request duration:\n")
-	fmt.Fprintf(w, "  Total:\t%v\n", total)
-	fmt.Fprintf(w, "  DNS lookup:\t%v\n", t.dnsDuration)
-	fmt.Fprintf(w, "  TLS handshake:\t%v\n", t.tlsDuration)
-	fmt.Fprintf(w, "  Connection:\t%v", t.connectDuration)
-	w.Flush()
-	t.logger.Print(buf.String())
+type tracerKey struct{}
+
+func withTracer(ctx context.Context, t *Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// Returns the tracer of the request context, if any.
+func tracerFromContext(ctx context.Context) *Tracer {
+	t, _ := ctx.Value(tracerKey{}).(*Tracer)
+	return t
+}
+
+// Report logs the timings of the hops so far.
+func (t *Tracer) Report() {
+	t.logger.Printf("Timing:\n%s", FormatTimings(t.Timings()))
+}
+
+// Timings returns the timings of the hops since the last reset.
+// The transfer and total time of a hop whose response body
+// has not yet been read are measured until now.
+func (t *Tracer) Timings() []Timing {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timings := make([]Timing, 0, len(t.hops))
+	for _, h := range t.hops {
+		timings = append(timings, h.snapshot())
+	}
+	return timings
+}
+
+// timingOf returns the timing of the hop that received res.
+func (t *Tracer) timingOf(res *http.Response) (Timing, bool) {
+	if t == nil {
+		return Timing{}, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h, found := t.responses[res]
+	if !found {
+		return Timing{}, false
+	}
+	return h.snapshot(), true
+}
+
+func (h *hop) snapshot() Timing {
+	timing := h.timing
+	if !h.done {
+		if !h.firstByte.IsZero() {
+			timing.Transfer = time.Since(h.firstByte)
+		}
+		timing.Total = time.Since(h.start)
+	}
+	return timing
+}
+
+func (t *Tracer) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hops = nil
+	t.current = nil
+	t.responses = nil
+}
+
+func (t *Tracer) startHop(req *http.Request) *hop {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h := &hop{
+		start: time.Now(),
+		timing: Timing{
+			Method: req.Method,
+			URL:    req.URL.String(),
+		},
+	}
+	t.hops = append(t.hops, h)
+	t.current = h
+	return h
 }
 
-func (t *Tracer) RoundTrip(req *http.Request) (*http.Response, error) {
-	t.currentRequest = req
-	return http.DefaultTransport.RoundTrip(req)
+func (t *Tracer) gotResponse(h *hop, res *http.Response) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h.timing.Status = res.StatusCode
+	h.timing.Location = res.Header.Get("Location")
+
+	if t.responses == nil {
+		t.responses = map[*http.Response]*hop{}
+	}
+	t.responses[res] = h
+}
+
+func (t *Tracer) finishHop(h *hop) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if h.done {
+		return
+	}
+
+	h.done = true
+	if !h.firstByte.IsZero() {
+		h.timing.Transfer = time.Since(h.firstByte)
+	}
+	h.timing.Total = time.Since(h.start)
+}
+
+// record calls fn with the current hop, if any.
+func (t *Tracer) record(fn func(h *hop)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current != nil {
+		fn(t.current)
+	}
 }
 
 func (t *Tracer) TLSHandshakeStart() {
-	t.tlsStart = time.Now()
+	t.record(func(h *hop) { h.tlsStart = time.Now() })
 }
 
 func (t *Tracer) TLSHandshakeDone(state tls.ConnectionState, err error) {
-	t.tlsDuration = time.Since(t.tlsStart)
+	var duration time.Duration
+	t.record(func(h *hop) {
+		duration = time.Since(h.tlsStart)
+		h.timing.TLS = duration
+	})
 	if err != nil {
-		t.logger.Printf("TLS handshake done after %v with error: %v", t.tlsDuration, err)
+		t.logger.Printf("TLS handshake done after %v with error: %v", duration, err)
 		return
 	}
-	t.logger.Printf("TLS handshake done after %v:", t.tlsDuration)
+	t.logger.Printf("TLS handshake done after %v:", duration)
 	t.logger.Printf("  Version: %d", state.Version)
 	t.logger.Printf("  Negotiated protocol: %s", state.NegotiatedProtocol)
 	if state.ServerName != "" {
@@ -82,30 +203,38 @@ func (t *Tracer) TLSHandshakeDone(state tls.ConnectionState, err error) {
 }
 
 func (t *Tracer) DNSStart(info httptrace.DNSStartInfo) {
-	t.dnsStart = time.Now()
+	t.record(func(h *hop) { h.dnsStart = time.Now() })
 	t.logger.Printf("Resolving DNS for host %s", info.Host)
 }
 
 func (t *Tracer) DNSDone(info httptrace.DNSDoneInfo) {
-	t.dnsDuration = time.Since(t.dnsStart)
+	var duration time.Duration
+	t.record(func(h *hop) {
+		duration = time.Since(h.dnsStart)
+		h.timing.DNS = duration
+	})
 	if info.Err != nil {
 		t.logger.Printf("Failed to during DNS lookup: %v", info.Err)
 	} else {
 		t.logger.Printf(
 			"DNS lookup done after %v: %s (coalesced = %v)",
-			t.dnsDuration,
+			duration,
 			info.Addrs,
 			info.Coalesced)
 	}
 }
 
 func (t *Tracer) ConnectStart(network, addr string) {
-	t.connectStart = time.Now()
+	t.record(func(h *hop) { h.connectStart = time.Now() })
 	t.logger.Printf("Attempting to connect on %s to %s", network, addr)
 }
 
 func (t *Tracer) ConnectDone(network, addr string, err error) {
-	t.connectDuration = time.Since(t.connectStart)
+	var duration time.Duration
+	t.record(func(h *hop) {
+		duration = time.Since(h.connectStart)
+		h.timing.Connect = duration
+	})
 	if err != nil {
 		t.logger.Printf("Failed to connect on %s to %s: %v", network, addr, err)
 	} else {
@@ -113,6 +242,61 @@ func (t *Tracer) ConnectDone(network, addr string, err error) {
 			"%s connection to %s established successfully after %v",
 			network,
 			addr,
-			t.connectDuration)
+			duration)
+	}
+}
+
+func (t *Tracer) GotConn(info httptrace.GotConnInfo) {
+	t.record(func(h *hop) {
+		h.gotConn = time.Now()
+		h.timing.Reused = info.Reused
+	})
+	if info.Reused {
+		t.logger.Printf("Reusing connection to %s", info.Conn.RemoteAddr())
+	}
+}
+
+func (t *Tracer) WroteRequest(info httptrace.WroteRequestInfo) {
+	t.record(func(h *hop) {
+		h.wroteRequest = time.Now()
+		if !h.gotConn.IsZero() {
+			h.timing.Send = h.wroteRequest.Sub(h.gotConn)
+		}
+	})
+	if info.Err != nil {
+		t.logger.Printf("Failed to write request: %v", info.Err)
+	}
+}
+
+func (t *Tracer) GotFirstResponseByte() {
+	t.record(func(h *hop) {
+		h.firstByte = time.Now()
+		if !h.wroteRequest.IsZero() {
+			h.timing.TTFB = h.firstByte.Sub(h.wroteRequest)
+		}
+	})
+}
+
+// DialOverride logs that the address of a connection was overridden.
+func (t *Tracer) DialOverride(format string, args ...any) {
+	if t == nil {
+		return
+	}
+	t.logger.Printf(format, args...)
+}
+
+// Proxy logs that the request to target is sent through proxy.
+func (t *Tracer) Proxy(proxy, target *url.URL) {
+	if t == nil {
+		return
+	}
+	t.logger.Printf("Using proxy %s for %s", proxy.Redacted(), target.Host)
+}
+
+// ProxyConnect logs the response of the proxy to a CONNECT request.
+func (t *Tracer) ProxyConnect(proxy *url.URL, req *http.Request, res *http.Response) {
+	if t == nil {
+		return
 	}
+	t.logger.Printf("Proxy %s responded to CONNECT %s: %s", proxy.Redacted(), req.Host, res.Status)
 }
@@ -2,6 +2,10 @@ package client
 
 import (
 	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"net/http/httptrace"
 	"testing"
 
@@ -24,20 +28,73 @@ func TestTracer(t *testing.T) {
 func TestTracerDNS(t *testing.T) {
 	logger := logging.NewLogger()
 	tracer := newTracer(logger)
+	hop := tracer.startHop(httptest.NewRequest("GET", "/", nil))
 
 	tracer.DNSStart(httptrace.DNSStartInfo{})
 	tracer.DNSDone(httptrace.DNSDoneInfo{})
 
-	require.NotZero(t, tracer.dnsStart)
-	require.NotZero(t, tracer.dnsDuration)
+	require.NotZero(t, hop.dnsStart)
+	require.NotZero(t, hop.timing.DNS)
 }
 
 func TestTracerTLS(t *testing.T) {
 	logger := logging.NewLogger()
 	tracer := newTracer(logger)
+	hop := tracer.startHop(httptest.NewRequest("GET", "/", nil))
 
 	tracer.TLSHandshakeStart()
-	require.NotZero(t, tracer.tlsStart)
+	require.NotZero(t, hop.tlsStart)
 
 	tracer.TLSHandshakeDone(tls.ConnectionState{}, nil)
+	require.NotZero(t, hop.timing.TLS)
+}
+
+func TestTracerTimingsPerHop(t *testing.T) {
+	redirects := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/target", http.StatusFound)
+			return
+		}
+		w.Write([]byte("body"))
+	}))
+	defer redirects.Close()
+
+	logger := logging.NewLogger()
+	client, err := NewClient(NewSettings(), logger, logger)
+	require.NoError(t, err)
+
+	url, _ := ParseURL(redirects.URL+"/redirect", nil)
+	req, err := client.BuildRequest("GET", url, nil, nil)
+	require.NoError(t, err)
+
+	res, err := client.Send(req)
+	require.NoError(t, err)
+	_, err = io.ReadAll(res.Body)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	timings := client.Timings()
+	require.Len(t, timings, 2)
+
+	first, second := timings[0], timings[1]
+	require.Equal(t, http.StatusFound, first.Status)
+	require.Equal(t, redirects.URL+"/redirect", first.URL)
+	require.NotZero(t, first.Connect)
+	require.NotZero(t, first.TTFB)
+	require.GreaterOrEqual(t, first.Total, first.Connect+first.TTFB)
+
+	require.Equal(t, http.StatusOK, second.Status)
+	require.Equal(t, redirects.URL+"/target", second.URL)
+	require.True(t, second.Reused)
+	require.Zero(t, second.Connect)
+	require.NotZero(t, second.Total)
+
+	b, err := json.Marshal(timings)
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"status":302`)
+	require.Contains(t, string(b), `"ttfb_ms":`)
+
+	table := FormatTimings(timings)
+	require.Contains(t, table, "TTFB")
+	require.Contains(t, table, "GET "+redirects.URL+"/target")
 }
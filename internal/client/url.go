@@ -7,6 +7,8 @@ import (
 	"strings"
 )
 
+const unixSocketPrefix = "unix:"
+
 var (
 	portPattern      = regexp.MustCompile(`^:\d+`)
 	protoPattern     = regexp.MustCompile(`^https?://`)
@@ -14,6 +16,7 @@ var (
 	hostPattern      = regexp.MustCompile(`^[a-z]+(\.[a-z]+)*`)
 	aliasPattern     = regexp.MustCompile(`\{[\w]+\}`)
 	schemePattern    = regexp.MustCompile(`^https?(:|:/|://)?$`)
+	wsProtoPattern   = regexp.MustCompile(`^wss?://`)
 )
 
 // ParseURL parses the given URL
@@ -54,6 +57,108 @@ func ParseURL(url string, aliases map[string]string) (*url.URL, error) {
 	return nil, fmt.Errorf("invalid URL format: %s", url)
 }
 
+// QueryParam is a query parameter, with a key and value that are not percent-encoded.
+type QueryParam struct {
+	Key   string
+	Value string
+}
+
+// Query is query parameters in the order they are given. Unlike
+// url.Values, the keys are not sorted when encoded, since the order
+// may matter to the server, e.g. of a signed URL.
+type Query []QueryParam
+
+// Get returns the first value of the key, or "" if none.
+func (q Query) Get(key string) string {
+	for _, p := range q {
+		if p.Key == key {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// Encode returns the percent-encoded parameters, in order.
+func (q Query) Encode() string {
+	params := make([]string, len(q))
+	for i, p := range q {
+		params[i] = url.QueryEscape(p.Key) + "=" + url.QueryEscape(p.Value)
+	}
+	return strings.Join(params, "&")
+}
+
+// AddQuery adds the query parameters to u, after any existing ones.
+func AddQuery(u *url.URL, query Query) {
+	if len(query) == 0 {
+		return
+	}
+
+	if u.RawQuery == "" {
+		u.RawQuery = query.Encode()
+	} else {
+		u.RawQuery += "&" + query.Encode()
+	}
+}
+
+// ParseWebSocketURL parses the given URL in the same way as ParseURL,
+// but also accepts the ws and wss schemes. The returned URL always
+// has a WebSocket scheme.
+func ParseWebSocketURL(url string, aliases map[string]string) (*url.URL, error) {
+	url = strings.TrimSpace(url)
+	if aliases != nil && aliasPattern.MatchString(url) {
+		var err error
+		url, err = substitute(url, aliases)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if wsProtoPattern.MatchString(url) {
+		url = "http" + strings.TrimPrefix(url, "ws")
+	}
+
+	u, err := ParseURL(url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	return u, nil
+}
+
+// SplitUnixSocketURL splits a URL in the format unix:/path/to.sock:/request/path
+// into the path of the socket and an HTTP URL to localhost with the request path.
+// Aliases are substituted before splitting. If the URL does not use the unix
+// scheme it is returned unchanged, along with an empty socket path.
+func SplitUnixSocketURL(url string, aliases map[string]string) (string, string, error) {
+	s := strings.TrimSpace(url)
+	if aliases != nil && aliasPattern.MatchString(s) {
+		var err error
+		s, err = substitute(s, aliases)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	rest, found := strings.CutPrefix(s, unixSocketPrefix)
+	if !found {
+		return url, "", nil
+	}
+
+	socket, path, _ := strings.Cut(rest, ":")
+	if socket == "" {
+		return "", "", fmt.Errorf("missing path of unix socket: %s", url)
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return "http://localhost" + path, socket, nil
+}
+
 func parseURL(s string) (*url.URL, error) {
 	s = strings.TrimSpace(s)
 	if s == "" {
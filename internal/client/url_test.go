@@ -83,3 +83,81 @@ func TestParseURL_Alias(t *testing.T) {
 		})
 	}
 }
+
+func TestParseWebSocketURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{":1234/ws", "ws://localhost:1234/ws"},
+		{"ws://localhost/ws", "ws://localhost/ws"},
+		{"wss://api.com/ws", "wss://api.com/ws"},
+		{"https://api.com/ws", "wss://api.com/ws"},
+		{"api.com/ws", "wss://api.com/ws"},
+		{"{test}/ws", "ws://localhost/ws"},
+	}
+
+	aliases := map[string]string{"test": "ws://localhost"}
+	for i, tt := range tests {
+		name := fmt.Sprintf("%d) ParseWebSocketURL(%s)", i, tt.url)
+		t.Run(name, func(t *testing.T) {
+			url, err := ParseWebSocketURL(tt.url, aliases)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, url.String())
+		})
+	}
+}
+
+func TestSplitUnixSocketURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+		socket   string
+	}{
+		{"unix:/var/run/docker.sock:/v1.43/containers/json", "http://localhost/v1.43/containers/json", "/var/run/docker.sock"},
+		{"unix:/tmp/app.sock", "http://localhost/", "/tmp/app.sock"},
+		{"{docker}/info", "http://localhost/info", "/var/run/docker.sock"},
+		{":1234/path", ":1234/path", ""},
+		{"{api}/path", "{api}/path", ""},
+	}
+
+	aliases := map[string]string{
+		"docker": "unix:/var/run/docker.sock:",
+		"api":    "https://api.com",
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			url, socket, err := SplitUnixSocketURL(tt.url, aliases)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, url)
+			require.Equal(t, tt.socket, socket)
+		})
+	}
+
+	_, _, err := SplitUnixSocketURL("unix::/path", nil)
+	require.Error(t, err)
+}
+
+func TestAddQuery(t *testing.T) {
+	aliases := map[string]string{"api": "https://api.example/v1?key=a%20b"}
+	tests := []struct {
+		url   string
+		query Query
+		want  string
+	}{
+		{"localhost/path", nil, "http://localhost/path"},
+		{"localhost/path", Query{{"q", "a b&c"}}, "http://localhost/path?q=a+b%26c"},
+		{"localhost/path?x=1#top", Query{{"q", "é"}}, "http://localhost/path?x=1&q=%C3%A9#top"},
+		{"{api}", Query{{"b", "2"}, {"a", "1"}, {"b", "3"}}, "https://api.example/v1?key=a%20b&b=2&a=1&b=3"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			u, err := ParseURL(test.url, aliases)
+			require.NoError(t, err)
+
+			AddQuery(u, test.query)
+			require.Equal(t, test.want, u.String())
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+
+	"github.com/gorilla/websocket"
+)
+
+// DialWebSocket performs the WebSocket upgrade against the given URL,
+// which must use the ws or wss scheme.
+func (client *Client) DialWebSocket(
+	ctx context.Context,
+	u *url.URL,
+	header http.Header,
+) (*websocket.Conn, *http.Response, error) {
+	dialer, err := client.settings.BuildWebSocketDialer()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client.clientLogger.Printf("Connecting to: %s", u.String())
+	client.logHeader("Request headers", header)
+
+	ctx = withTracer(httptrace.WithClientTrace(ctx, client.clientTrace), client.tracer)
+	conn, res, err := dialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		client.clientLogger.Printf("WebSocket handshake failed: %v", err)
+		if res != nil {
+			client.clientLogger.Printf("Response status: %s", res.Status)
+		}
+		return nil, res, err
+	}
+
+	client.clientLogger.Printf("Response status: %s", res.Status)
+	client.logHeader("Response headers", res.Header)
+	return conn, res, nil
+}
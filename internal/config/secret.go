@@ -0,0 +1,31 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ResolveSecret returns the value of a secret reference, which is one of:
+//   - env:NAME, the value of the environment variable NAME
+//   - file:PATH, the content of the file at PATH
+//   - any other value is used as is
+func ResolveSecret(ref string) (string, error) {
+	if name, found := strings.CutPrefix(ref, "env:"); found {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable not set: %s", name)
+		}
+		return value, nil
+	}
+
+	if filepath, found := strings.CutPrefix(ref, "file:"); found {
+		b, err := os.ReadFile(filepath)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	return ref, nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSecret(t *testing.T) {
+	t.Setenv("TEST_SECRET", "from-env")
+	filepath := path.Join(t.TempDir(), "secret")
+	os.WriteFile(filepath, []byte("from-file\n"), 0600)
+
+	tests := []struct {
+		ref      string
+		expected string
+		wantErr  bool
+	}{
+		{"literal", "literal", false},
+		{"env:TEST_SECRET", "from-env", false},
+		{"file:" + filepath, "from-file", false},
+		{"env:TEST_SECRET_NOT_SET", "", true},
+		{"file:/does/not/exist", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			value, err := ResolveSecret(tt.ref)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Signer is the configuration of a named signer. The type key
+// selects the kind of signer and the other keys depend on the type.
+type Signer map[string]any
+
+func (s Signer) Type() string {
+	t, _ := s["type"].(string)
+	return t
+}
+
+// String returns the string value of key, or def if it is not set.
+func (s Signer) String(key, def string) (string, error) {
+	v, found := s[key]
+	if !found {
+		return def, nil
+	}
+
+	str, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("expected %s to be a string", key)
+	}
+	return str, nil
+}
+
+// Secret returns the resolved value of the secret reference in key,
+// which must be set.
+func (s Signer) Secret(key string) (string, error) {
+	ref, err := s.String(key, "")
+	if err != nil {
+		return "", err
+	}
+	if ref == "" {
+		return "", fmt.Errorf("missing %s", key)
+	}
+	return ResolveSecret(ref)
+}
+
+// Duration returns the duration value of key, or def if it is not set.
+func (s Signer) Duration(key string, def time.Duration) (time.Duration, error) {
+	str, err := s.String(key, "")
+	if err != nil || str == "" {
+		return def, err
+	}
+	return time.ParseDuration(str)
+}
+
+// Table returns the table value of key, or an empty table if it is not set.
+func (s Signer) Table(key string) (map[string]any, error) {
+	v, found := s[key]
+	if !found {
+		return map[string]any{}, nil
+	}
+
+	table, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected %s to be a table", key)
+	}
+	return table, nil
+}
@@ -0,0 +1,88 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Request is the body of a GraphQL request over HTTP.
+type Request struct {
+	Query         string          `json:"query"`
+	OperationName string          `json:"operationName,omitempty"`
+	Variables     json.RawMessage `json:"variables,omitempty"`
+}
+
+// NewRequest returns a request of the query, where variables
+// must be a JSON object if not empty.
+func NewRequest(query, operation, variables string) (Request, error) {
+	req := Request{
+		Query:         query,
+		OperationName: operation,
+	}
+	if strings.TrimSpace(query) == "" {
+		return req, fmt.Errorf("empty query")
+	}
+
+	if variables != "" {
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(variables), &obj); err != nil || obj == nil {
+			return req, fmt.Errorf("variables must be a JSON object")
+		}
+		req.Variables = json.RawMessage(variables)
+	}
+	return req, nil
+}
+
+// Response is the body of a response to a GraphQL request.
+type Response struct {
+	Data   json.RawMessage `json:"data,omitempty"`
+	Errors []Error         `json:"errors,omitempty"`
+}
+
+// ParseResponse returns the response of the body, or an
+// error if it is not a GraphQL response.
+func ParseResponse(body []byte) (Response, error) {
+	var res Response
+	if err := json.Unmarshal(body, &res); err != nil {
+		return res, err
+	}
+	if res.Data == nil && res.Errors == nil {
+		return res, fmt.Errorf("neither data nor errors in response")
+	}
+	return res, nil
+}
+
+// Error is an error in a GraphQL response.
+type Error struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	Path      []any      `json:"path,omitempty"`
+}
+
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// String returns the message with the path and
+// location of the error, e.g. "not found at user.name (1:3)".
+func (e Error) String() string {
+	s := e.Message
+	if len(e.Path) > 0 {
+		path := make([]string, len(e.Path))
+		for i, seg := range e.Path {
+			path[i] = fmt.Sprint(seg)
+		}
+		s += " at " + strings.Join(path, ".")
+	}
+
+	if len(e.Locations) > 0 {
+		locations := make([]string, len(e.Locations))
+		for i, loc := range e.Locations {
+			locations[i] = fmt.Sprintf("%d:%d", loc.Line, loc.Column)
+		}
+		s += " (" + strings.Join(locations, ", ") + ")"
+	}
+	return s
+}
@@ -0,0 +1,171 @@
+package graphql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRequest(t *testing.T) {
+	req, err := NewRequest("query User($id: ID!) { user(id: $id) { name } }", "User", `{"id": "1"}`)
+	require.NoError(t, err)
+
+	b, err := json.Marshal(req)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"query": "query User($id: ID!) { user(id: $id) { name } }",
+		"operationName": "User",
+		"variables": {"id": "1"}
+	}`, string(b))
+
+	req, err = NewRequest("{ me { name } }", "", "")
+	require.NoError(t, err)
+	b, err = json.Marshal(req)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"query": "{ me { name } }"}`, string(b))
+
+	_, err = NewRequest(" ", "", "")
+	require.Error(t, err)
+	_, err = NewRequest("{ me }", "", `[1]`)
+	require.Error(t, err)
+	_, err = NewRequest("{ me }", "", `{`)
+	require.Error(t, err)
+}
+
+func TestParseResponse(t *testing.T) {
+	res, err := ParseResponse([]byte(`{
+		"data": {"user": null},
+		"errors": [{"message": "not found", "path": ["user", 0, "name"], "locations": [{"line": 1, "column": 3}]}]
+	}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"user": null}`, string(res.Data))
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "not found at user.0.name (1:3)", res.Errors[0].String())
+
+	_, err = ParseResponse([]byte(`{"message": "bad gateway"}`))
+	require.Error(t, err)
+	_, err = ParseResponse([]byte(`<html></html>`))
+	require.Error(t, err)
+}
+
+func TestPrintSchema(t *testing.T) {
+	data := `{"__schema": {
+		"queryType": {"name": "Query"},
+		"mutationType": {"name": "Mutation"},
+		"subscriptionType": null,
+		"directives": [
+			{"name": "skip", "locations": ["FIELD"], "args": []},
+			{"name": "auth", "description": "Requires a role.", "locations": ["FIELD_DEFINITION", "OBJECT"],
+			 "args": [{"name": "role", "type": {"kind": "SCALAR", "name": "String"}, "defaultValue": "\"user\""}]},
+			{"name": "tag", "isRepeatable": true, "locations": ["OBJECT"], "args": [{"name": "name", "type": {"kind": "SCALAR", "name": "String"}}]}
+		],
+		"types": [
+			{"kind": "SCALAR", "name": "String"},
+			{"kind": "SCALAR", "name": "DateTime", "description": "An ISO 8601 timestamp."},
+			{"kind": "SCALAR", "name": "UUID", "description": "A \"UUID\"", "specifiedByURL": "https://www.rfc-editor.org/rfc/rfc4122"},
+			{"kind": "OBJECT", "name": "__Schema", "fields": []},
+			{"kind": "OBJECT", "name": "Query", "interfaces": [], "fields": [
+				{"name": "user", "args": [{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}],
+				 "type": {"kind": "OBJECT", "name": "User"}},
+				{"name": "users", "description": "All users,\nby name.", "args": [
+					{"name": "first", "description": "Number of users.", "type": {"kind": "SCALAR", "name": "Int"}, "defaultValue": "10"}],
+				 "type": {"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "User"}}}}}
+			]},
+			{"kind": "OBJECT", "name": "Mutation", "fields": [
+				{"name": "createUser", "args": [{"name": "input", "type": {"kind": "INPUT_OBJECT", "name": "UserInput"}}],
+				 "type": {"kind": "OBJECT", "name": "User"}}
+			]},
+			{"kind": "INTERFACE", "name": "Node", "fields": [
+				{"name": "id", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}
+			]},
+			{"kind": "OBJECT", "name": "User", "interfaces": [{"kind": "INTERFACE", "name": "Node"}], "fields": [
+				{"name": "id", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+				{"name": "login", "args": [], "type": {"kind": "SCALAR", "name": "String"}, "isDeprecated": true, "deprecationReason": "Use \"name\"."},
+				{"name": "age", "args": [], "type": {"kind": "SCALAR", "name": "Int"}, "isDeprecated": true, "deprecationReason": "No longer supported"},
+				{"name": "role", "args": [], "type": {"kind": "ENUM", "name": "Role"}}
+			]},
+			{"kind": "ENUM", "name": "Role", "enumValues": [
+				{"name": "ADMIN", "description": "Can do anything."},
+				{"name": "USER"}
+			]},
+			{"kind": "UNION", "name": "SearchResult", "possibleTypes": [{"kind": "OBJECT", "name": "User"}, {"kind": "OBJECT", "name": "Post"}]},
+			{"kind": "INPUT_OBJECT", "name": "UserInput", "inputFields": [
+				{"name": "name", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+				{"name": "role", "type": {"kind": "ENUM", "name": "Role"}, "defaultValue": "USER"}
+			]}
+		]
+	}}`
+
+	sdl, err := PrintSchema(json.RawMessage(data))
+	require.NoError(t, err)
+	require.Equal(t, `"""Requires a role."""
+directive @auth(role: String = "user") on FIELD_DEFINITION | OBJECT
+
+directive @tag(name: String) repeatable on OBJECT
+
+"""An ISO 8601 timestamp."""
+scalar DateTime
+
+"""
+A "UUID"
+"""
+scalar UUID @specifiedBy(url: "https://www.rfc-editor.org/rfc/rfc4122")
+
+type Query {
+  user(id: ID!): User
+  """
+  All users,
+  by name.
+  """
+  users(
+    """Number of users."""
+    first: Int = 10
+  ): [User!]!
+}
+
+type Mutation {
+  createUser(input: UserInput): User
+}
+
+interface Node {
+  id: ID!
+}
+
+type User implements Node {
+  id: ID!
+  login: String @deprecated(reason: "Use \"name\".")
+  age: Int @deprecated
+  role: Role
+}
+
+enum Role {
+  """Can do anything."""
+  ADMIN
+  USER
+}
+
+union SearchResult = User | Post
+
+input UserInput {
+  name: String!
+  role: Role = USER
+}
+`, sdl)
+}
+
+func TestPrintSchemaDefinition(t *testing.T) {
+	data := `{"__schema": {
+		"queryType": {"name": "RootQuery"},
+		"types": [{"kind": "OBJECT", "name": "RootQuery", "fields": [
+			{"name": "ok", "args": [], "type": {"kind": "SCALAR", "name": "Boolean"}}
+		]}]
+	}}`
+
+	sdl, err := PrintSchema(json.RawMessage(data))
+	require.NoError(t, err)
+	require.Equal(t, "schema {\n  query: RootQuery\n}\n\ntype RootQuery {\n  ok: Boolean\n}\n", sdl)
+
+	_, err = PrintSchema(json.RawMessage(`{"user": {}}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,382 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// IntrospectionOperation is the name of the operation in IntrospectionQuery.
+const IntrospectionOperation = "IntrospectionQuery"
+
+// IntrospectionQuery requests the schema of the server, as printed by PrintSchema.
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives {
+      name
+      description
+      isRepeatable
+      locations
+      args { ...InputValue }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  specifiedByURL
+  fields(includeDeprecated: true) {
+    name
+    description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+        ofType {
+          kind
+          name
+          ofType {
+            kind
+            name
+            ofType {
+              kind
+              name
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// defaultDeprecationReason is omitted when printing @deprecated.
+const defaultDeprecationReason = "No longer supported"
+
+var (
+	builtinScalars    = []string{"String", "Int", "Float", "Boolean", "ID"}
+	builtinDirectives = []string{"include", "skip", "deprecated", "specifiedBy", "oneOf"}
+)
+
+type schema struct {
+	QueryType        *namedType  `json:"queryType"`
+	MutationType     *namedType  `json:"mutationType"`
+	SubscriptionType *namedType  `json:"subscriptionType"`
+	Types            []fullType  `json:"types"`
+	Directives       []directive `json:"directives"`
+}
+
+type namedType struct {
+	Name string `json:"name"`
+}
+
+type fullType struct {
+	Kind           string       `json:"kind"`
+	Name           string       `json:"name"`
+	Description    string       `json:"description"`
+	SpecifiedByURL string       `json:"specifiedByURL"`
+	Fields         []field      `json:"fields"`
+	InputFields    []inputValue `json:"inputFields"`
+	Interfaces     []typeRef    `json:"interfaces"`
+	EnumValues     []enumValue  `json:"enumValues"`
+	PossibleTypes  []typeRef    `json:"possibleTypes"`
+}
+
+type field struct {
+	Name              string       `json:"name"`
+	Description       string       `json:"description"`
+	Args              []inputValue `json:"args"`
+	Type              typeRef      `json:"type"`
+	IsDeprecated      bool         `json:"isDeprecated"`
+	DeprecationReason string       `json:"deprecationReason"`
+}
+
+type inputValue struct {
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	Type         typeRef `json:"type"`
+	DefaultValue *string `json:"defaultValue"`
+}
+
+type enumValue struct {
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	IsDeprecated      bool   `json:"isDeprecated"`
+	DeprecationReason string `json:"deprecationReason"`
+}
+
+type directive struct {
+	Name         string       `json:"name"`
+	Description  string       `json:"description"`
+	IsRepeatable bool         `json:"isRepeatable"`
+	Locations    []string     `json:"locations"`
+	Args         []inputValue `json:"args"`
+}
+
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+func (t typeRef) String() string {
+	switch {
+	case t.Kind == "NON_NULL" && t.OfType != nil:
+		return t.OfType.String() + "!"
+	case t.Kind == "LIST" && t.OfType != nil:
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// PrintSchema returns the schema in the data of a response to
+// IntrospectionQuery in the schema definition language (SDL).
+// Built-in scalars and directives are not included.
+func PrintSchema(data json.RawMessage) (string, error) {
+	var result struct {
+		Schema *schema `json:"__schema"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", err
+	}
+	if result.Schema == nil {
+		return "", fmt.Errorf("no schema in introspection result")
+	}
+
+	s := result.Schema
+	defs := []string{}
+	if def := s.printSchemaDefinition(); def != "" {
+		defs = append(defs, def)
+	}
+
+	for _, d := range s.Directives {
+		if !slices.Contains(builtinDirectives, d.Name) {
+			defs = append(defs, d.print())
+		}
+	}
+
+	for _, t := range s.Types {
+		if strings.HasPrefix(t.Name, "__") || (t.Kind == "SCALAR" && slices.Contains(builtinScalars, t.Name)) {
+			continue
+		}
+		defs = append(defs, t.print())
+	}
+	return strings.Join(defs, "\n\n") + "\n", nil
+}
+
+// The schema definition is omitted if the root
+// types have their default names, as in graphql-js.
+func (s *schema) printSchemaDefinition() string {
+	roots := []struct {
+		operation string
+		typ       *namedType
+		name      string
+	}{
+		{"query", s.QueryType, "Query"},
+		{"mutation", s.MutationType, "Mutation"},
+		{"subscription", s.SubscriptionType, "Subscription"},
+	}
+
+	conventional := true
+	lines := []string{}
+	for _, root := range roots {
+		if root.typ == nil {
+			continue
+		}
+		conventional = conventional && root.typ.Name == root.name
+		lines = append(lines, fmt.Sprintf("  %s: %s", root.operation, root.typ.Name))
+	}
+
+	if conventional {
+		return ""
+	}
+	return "schema {\n" + strings.Join(lines, "\n") + "\n}"
+}
+
+func (d directive) print() string {
+	repeatable := ""
+	if d.IsRepeatable {
+		repeatable = " repeatable"
+	}
+	return printDescription(d.Description, "") +
+		"directive @" + d.Name + printArgs(d.Args, "") + repeatable +
+		" on " + strings.Join(d.Locations, " | ")
+}
+
+func (t fullType) print() string {
+	b := &strings.Builder{}
+	b.WriteString(printDescription(t.Description, ""))
+
+	switch t.Kind {
+	case "SCALAR":
+		fmt.Fprintf(b, "scalar %s", t.Name)
+		if t.SpecifiedByURL != "" {
+			url, _ := json.Marshal(t.SpecifiedByURL)
+			fmt.Fprintf(b, " @specifiedBy(url: %s)", url)
+		}
+	case "OBJECT", "INTERFACE":
+		keyword := "type"
+		if t.Kind == "INTERFACE" {
+			keyword = "interface"
+		}
+		fmt.Fprintf(b, "%s %s%s", keyword, t.Name, printImplements(t.Interfaces))
+
+		lines := make([]string, len(t.Fields))
+		for i, f := range t.Fields {
+			lines[i] = printDescription(f.Description, "  ") +
+				"  " + f.Name + printArgs(f.Args, "  ") + ": " + f.Type.String() +
+				printDeprecated(f.IsDeprecated, f.DeprecationReason)
+		}
+		b.WriteString(printBlock(lines))
+	case "UNION":
+		names := make([]string, len(t.PossibleTypes))
+		for i, p := range t.PossibleTypes {
+			names[i] = p.String()
+		}
+		fmt.Fprintf(b, "union %s", t.Name)
+		if len(names) > 0 {
+			b.WriteString(" = " + strings.Join(names, " | "))
+		}
+	case "ENUM":
+		fmt.Fprintf(b, "enum %s", t.Name)
+		lines := make([]string, len(t.EnumValues))
+		for i, v := range t.EnumValues {
+			lines[i] = printDescription(v.Description, "  ") +
+				"  " + v.Name + printDeprecated(v.IsDeprecated, v.DeprecationReason)
+		}
+		b.WriteString(printBlock(lines))
+	case "INPUT_OBJECT":
+		fmt.Fprintf(b, "input %s", t.Name)
+		lines := make([]string, len(t.InputFields))
+		for i, f := range t.InputFields {
+			lines[i] = printDescription(f.Description, "  ") + "  " + f.print()
+		}
+		b.WriteString(printBlock(lines))
+	default:
+		fmt.Fprintf(b, "# unknown kind %s: %s", t.Kind, t.Name)
+	}
+	return b.String()
+}
+
+func (v inputValue) print() string {
+	s := v.Name + ": " + v.Type.String()
+	if v.DefaultValue != nil {
+		s += " = " + *v.DefaultValue
+	}
+	return s
+}
+
+func printImplements(interfaces []typeRef) string {
+	if len(interfaces) == 0 {
+		return ""
+	}
+
+	names := make([]string, len(interfaces))
+	for i, iface := range interfaces {
+		names[i] = iface.String()
+	}
+	return " implements " + strings.Join(names, " & ")
+}
+
+// Arguments are printed on one line, unless any of them has a description.
+func printArgs(args []inputValue, indent string) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	described := slices.ContainsFunc(args, func(arg inputValue) bool {
+		return arg.Description != ""
+	})
+
+	printed := make([]string, len(args))
+	for i, arg := range args {
+		if described {
+			printed[i] = printDescription(arg.Description, indent+"  ") + indent + "  " + arg.print()
+		} else {
+			printed[i] = arg.print()
+		}
+	}
+
+	if described {
+		return "(\n" + strings.Join(printed, "\n") + "\n" + indent + ")"
+	}
+	return "(" + strings.Join(printed, ", ") + ")"
+}
+
+func printBlock(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return " {\n" + strings.Join(lines, "\n") + "\n}"
+}
+
+func printDeprecated(deprecated bool, reason string) string {
+	if !deprecated {
+		return ""
+	}
+	if reason == "" || reason == defaultDeprecationReason {
+		return " @deprecated"
+	}
+
+	quoted, _ := json.Marshal(reason)
+	return fmt.Sprintf(" @deprecated(reason: %s)", quoted)
+}
+
+// Returns the description as a block string on its own lines, if any.
+// It is printed on a single line unless it has several lines, or ends
+// with a quote or backslash that would run into the closing quotes.
+func printDescription(description, indent string) string {
+	if description == "" {
+		return ""
+	}
+
+	description = strings.ReplaceAll(description, `"""`, `\"""`)
+	if !strings.Contains(description, "\n") && !strings.HasSuffix(description, `"`) && !strings.HasSuffix(description, `\`) {
+		return indent + `"""` + description + `"""` + "\n"
+	}
+
+	lines := strings.Split(description, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	return indent + `"""` + "\n" + strings.Join(lines, "\n") + "\n" + indent + `"""` + "\n"
+}
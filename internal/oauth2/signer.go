@@ -0,0 +1,37 @@
+package oauth2
+
+import (
+	"io"
+	"net/http"
+)
+
+// Signer sets the Authorization header using the access token of a Source.
+// When the server rejects the token it is invalidated and a new one is obtained.
+type Signer struct {
+	source     *Source
+	challenged bool
+}
+
+func NewSigner(source *Source) *Signer {
+	return &Signer{source: source}
+}
+
+func (s *Signer) Sign(r *http.Request, body io.ReadSeeker) error {
+	token, err := s.source.Token()
+	if err != nil {
+		return err
+	}
+
+	r.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	return nil
+}
+
+func (s *Signer) Challenge(res *http.Response) (bool, error) {
+	if s.challenged {
+		return false, nil
+	}
+
+	s.challenged = true
+	s.source.logger.Print("Access token was rejected, obtaining a new one")
+	return true, s.source.Invalidate()
+}
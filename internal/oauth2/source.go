@@ -0,0 +1,225 @@
+package oauth2
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lunjon/http/internal/config"
+)
+
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantRefreshToken      = "refresh_token"
+
+	AuthStyleHeader = "header"
+	AuthStyleBody   = "body"
+)
+
+// Source obtains access tokens from a token endpoint,
+// caching them until they expire.
+type Source struct {
+	name       string
+	cfg        config.OAuth2
+	cache      *Cache
+	httpClient *http.Client
+	logger     *log.Logger
+}
+
+func NewSource(
+	name string,
+	cfg config.OAuth2,
+	cache *Cache,
+	httpClient *http.Client,
+	logger *log.Logger,
+) (*Source, error) {
+	if cfg.TokenURL == "" {
+		return nil, fmt.Errorf("oauth2 %s: missing token_url", name)
+	}
+
+	switch cfg.GrantType {
+	case "":
+		cfg.GrantType = GrantClientCredentials
+	case GrantClientCredentials, GrantRefreshToken:
+	default:
+		return nil, fmt.Errorf("oauth2 %s: unsupported grant_type: %s", name, cfg.GrantType)
+	}
+
+	switch cfg.AuthStyle {
+	case "":
+		cfg.AuthStyle = AuthStyleHeader
+	case AuthStyleHeader, AuthStyleBody:
+	default:
+		return nil, fmt.Errorf("oauth2 %s: invalid auth_style: %s", name, cfg.AuthStyle)
+	}
+
+	return &Source{
+		name:       name,
+		cfg:        cfg,
+		cache:      cache,
+		httpClient: httpClient,
+		logger:     logger,
+	}, nil
+}
+
+// Token returns a valid access token, from the cache if possible.
+func (s *Source) Token() (Token, error) {
+	key := s.cacheKey()
+	cached, found, err := s.cache.Load(key)
+	if err != nil {
+		s.logger.Printf("Ignoring invalid cached token: %v", err)
+	}
+	if found && cached.Valid() {
+		s.logger.Printf("Using cached OAuth2 token for %s", s.name)
+		return cached, nil
+	}
+
+	var token Token
+	if found && cached.RefreshToken != "" {
+		token, err = s.refresh(cached.RefreshToken)
+		if err != nil {
+			// The refresh token may have been revoked or expired
+			s.logger.Printf("Failed to refresh cached OAuth2 token, using grant %s: %v", s.cfg.GrantType, err)
+			if err := s.cache.Delete(key); err != nil {
+				s.logger.Printf("Failed to remove cached token: %v", err)
+			}
+			token, err = s.grant()
+		}
+	} else {
+		token, err = s.grant()
+	}
+	if err != nil {
+		return token, err
+	}
+
+	if err := s.cache.Save(key, token); err != nil {
+		s.logger.Printf("Failed to cache token: %v", err)
+	}
+	return token, nil
+}
+
+// Invalidate removes the cached access token, but keeps any refresh token.
+func (s *Source) Invalidate() error {
+	key := s.cacheKey()
+	cached, found, _ := s.cache.Load(key)
+	if !found {
+		return nil
+	}
+	return s.cache.Save(key, Token{RefreshToken: cached.RefreshToken})
+}
+
+// cacheKey returns the name of the cached token, which includes a hash of
+// the configuration so that tokens are not used if the configuration changes.
+func (s *Source) cacheKey() string {
+	h := sha256.New()
+	for _, field := range []string{s.cfg.TokenURL, s.cfg.ClientID, s.cfg.GrantType, s.cfg.Audience} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	for _, scope := range s.cfg.Scopes {
+		h.Write([]byte(scope))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%s-%x", s.name, h.Sum(nil)[:8])
+}
+
+// grant obtains a new token using the configured grant type.
+func (s *Source) grant() (Token, error) {
+	if s.cfg.GrantType == GrantRefreshToken {
+		refreshToken, err := config.ResolveSecret(s.cfg.RefreshToken)
+		if err != nil {
+			return Token{}, err
+		}
+		return s.refresh(refreshToken)
+	}
+	return s.fetch(url.Values{"grant_type": {GrantClientCredentials}})
+}
+
+func (s *Source) refresh(refreshToken string) (Token, error) {
+	token, err := s.fetch(url.Values{
+		"grant_type":    {GrantRefreshToken},
+		"refresh_token": {refreshToken},
+	})
+	// Servers may omit the refresh token if it remains the same
+	if err == nil && token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, err
+}
+
+func (s *Source) fetch(params url.Values) (Token, error) {
+	s.logger.Printf("Requesting OAuth2 token for %s using grant %s", s.name, params.Get("grant_type"))
+
+	secret, err := config.ResolveSecret(s.cfg.ClientSecret)
+	if err != nil {
+		return Token{}, err
+	}
+
+	if len(s.cfg.Scopes) > 0 {
+		params.Set("scope", strings.Join(s.cfg.Scopes, " "))
+	}
+	if s.cfg.Audience != "" {
+		params.Set("audience", s.cfg.Audience)
+	}
+	if s.cfg.AuthStyle == AuthStyleBody {
+		params.Set("client_id", s.cfg.ClientID)
+		if secret != "" {
+			params.Set("client_secret", secret)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.cfg.TokenURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if s.cfg.AuthStyle == AuthStyleHeader && s.cfg.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(s.cfg.ClientID), url.QueryEscape(secret))
+	}
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return Token{}, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return Token{}, err
+	}
+
+	if res.StatusCode >= 400 {
+		return Token{}, fmt.Errorf("failed to obtain OAuth2 token: %s: %s", res.Status, strings.TrimSpace(string(b)))
+	}
+
+	var body struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return Token{}, fmt.Errorf("invalid OAuth2 token response: %w", err)
+	}
+	if body.AccessToken == "" {
+		return Token{}, fmt.Errorf("invalid OAuth2 token response: missing access_token")
+	}
+
+	token := Token{
+		AccessToken:  body.AccessToken,
+		TokenType:    body.TokenType,
+		RefreshToken: body.RefreshToken,
+	}
+	if body.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	s.logger.Printf("Obtained OAuth2 token (expires: %v)", token.Expiry)
+	return token, nil
+}
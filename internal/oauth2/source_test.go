@@ -0,0 +1,184 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lunjon/http/internal/config"
+	"github.com/lunjon/http/internal/logging"
+	"github.com/stretchr/testify/require"
+)
+
+type tokenServer struct {
+	server *httptest.Server
+	grants []string
+	// Returned as expires_in
+	expiresIn int
+	// Refresh tokens are rejected if set
+	rejectRefresh bool
+}
+
+func setupTokenServer(t *testing.T) *tokenServer {
+	ts := &tokenServer{expiresIn: 3600}
+	ts.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		r.ParseForm()
+		ts.grants = append(ts.grants, r.PostForm.Get("grant_type"))
+		if ts.rejectRefresh && r.PostForm.Get("grant_type") == GrantRefreshToken {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "invalid_grant"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  fmt.Sprintf("token-%d", len(ts.grants)),
+			"token_type":    "Bearer",
+			"expires_in":    ts.expiresIn,
+			"refresh_token": "refresh",
+		})
+	}))
+	t.Cleanup(ts.server.Close)
+	return ts
+}
+
+func setupSource(t *testing.T, ts *tokenServer, cfg config.OAuth2) *Source {
+	cfg.TokenURL = ts.server.URL
+	cfg.ClientID = "client"
+	cfg.ClientSecret = "env:TEST_CLIENT_SECRET"
+	t.Setenv("TEST_CLIENT_SECRET", "secret")
+
+	source, err := NewSource("test", cfg, NewCache(t.TempDir()), http.DefaultClient, logging.NewSilentLogger())
+	require.NoError(t, err)
+	return source
+}
+
+func TestSourceClientCredentials(t *testing.T) {
+	ts := setupTokenServer(t)
+	source := setupSource(t, ts, config.OAuth2{})
+
+	token, err := source.Token()
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token.AccessToken)
+	require.True(t, token.Valid())
+
+	// The token should be cached
+	token, err = source.Token()
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token.AccessToken)
+	require.Equal(t, []string{GrantClientCredentials}, ts.grants)
+}
+
+func TestSourceRefreshExpired(t *testing.T) {
+	ts := setupTokenServer(t)
+	ts.expiresIn = 1
+	source := setupSource(t, ts, config.OAuth2{})
+
+	_, err := source.Token()
+	require.NoError(t, err)
+
+	// Expires within the expiry delta, so it is refreshed
+	token, err := source.Token()
+	require.NoError(t, err)
+	require.Equal(t, "token-2", token.AccessToken)
+	require.Equal(t, []string{GrantClientCredentials, GrantRefreshToken}, ts.grants)
+}
+
+func TestSourceRefreshRevoked(t *testing.T) {
+	ts := setupTokenServer(t)
+	ts.expiresIn = 1
+	source := setupSource(t, ts, config.OAuth2{})
+
+	_, err := source.Token()
+	require.NoError(t, err)
+
+	// Falls back to the configured grant if the refresh token is rejected
+	ts.rejectRefresh = true
+	token, err := source.Token()
+	require.NoError(t, err)
+	require.Equal(t, "token-3", token.AccessToken)
+	require.Equal(t, []string{GrantClientCredentials, GrantRefreshToken, GrantClientCredentials}, ts.grants)
+}
+
+func TestSourceCacheKey(t *testing.T) {
+	ts := setupTokenServer(t)
+	cache := NewCache(t.TempDir())
+	newSource := func(scopes ...string) *Source {
+		cfg := config.OAuth2{TokenURL: ts.server.URL, ClientID: "client", ClientSecret: "secret", Scopes: scopes}
+		source, err := NewSource("test", cfg, cache, http.DefaultClient, logging.NewSilentLogger())
+		require.NoError(t, err)
+		return source
+	}
+
+	token, err := newSource("read").Token()
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token.AccessToken)
+
+	// A token is not used for another configuration
+	token, err = newSource("read", "write").Token()
+	require.NoError(t, err)
+	require.Equal(t, "token-2", token.AccessToken)
+
+	token, err = newSource("read").Token()
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token.AccessToken)
+}
+
+func TestSourceRefreshTokenGrant(t *testing.T) {
+	ts := setupTokenServer(t)
+	source := setupSource(t, ts, config.OAuth2{GrantType: GrantRefreshToken, RefreshToken: "initial"})
+
+	_, err := source.Token()
+	require.NoError(t, err)
+	require.Equal(t, []string{GrantRefreshToken}, ts.grants)
+}
+
+func TestSourceInvalidConfig(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	logger := logging.NewSilentLogger()
+	tests := []config.OAuth2{
+		{},
+		{TokenURL: "http://localhost", GrantType: "password"},
+		{TokenURL: "http://localhost", AuthStyle: "cookie"},
+	}
+
+	for _, cfg := range tests {
+		_, err := NewSource("test", cfg, cache, http.DefaultClient, logger)
+		require.Error(t, err)
+	}
+}
+
+func TestSignerChallenge(t *testing.T) {
+	ts := setupTokenServer(t)
+	signer := NewSigner(setupSource(t, ts, config.OAuth2{}))
+
+	req, _ := http.NewRequest("GET", "http://localhost", nil)
+	require.NoError(t, signer.Sign(req, nil))
+	require.Equal(t, "Bearer token-1", req.Header.Get("Authorization"))
+
+	retry, err := signer.Challenge(&http.Response{StatusCode: http.StatusUnauthorized})
+	require.NoError(t, err)
+	require.True(t, retry)
+
+	require.NoError(t, signer.Sign(req, nil))
+	require.Equal(t, "Bearer token-2", req.Header.Get("Authorization"))
+	require.Equal(t, []string{GrantClientCredentials, GrantRefreshToken}, ts.grants)
+
+	retry, err = signer.Challenge(&http.Response{StatusCode: http.StatusUnauthorized})
+	require.NoError(t, err)
+	require.False(t, retry)
+}
+
+func TestTokenValid(t *testing.T) {
+	require.False(t, Token{}.Valid())
+	require.True(t, Token{AccessToken: "a"}.Valid())
+	require.True(t, Token{AccessToken: "a", Expiry: time.Now().Add(time.Hour)}.Valid())
+	require.False(t, Token{AccessToken: "a", Expiry: time.Now()}.Valid())
+}
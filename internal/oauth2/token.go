@@ -0,0 +1,78 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"time"
+)
+
+// Tokens are considered expired this long before they actually expire,
+// so that they do not expire while a request is sent.
+const expiryDelta = time.Second * 30
+
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitzero"`
+}
+
+// Valid reports whether the token can be used.
+// A token without expiry is valid until the server rejects it.
+func (t Token) Valid() bool {
+	if t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || time.Now().Add(expiryDelta).Before(t.Expiry)
+}
+
+// Cache stores tokens as files in a directory.
+type Cache struct {
+	dir string
+}
+
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+func (c *Cache) filepath(name string) string {
+	return path.Join(c.dir, name+".json")
+}
+
+// Load returns the cached token, and false if there is none.
+func (c *Cache) Load(name string) (Token, bool, error) {
+	var token Token
+	b, err := os.ReadFile(c.filepath(name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return token, false, nil
+		}
+		return token, false, err
+	}
+
+	err = json.Unmarshal(b, &token)
+	return token, err == nil, err
+}
+
+func (c *Cache) Save(name string, token Token) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.filepath(name), b, 0600)
+}
+
+// Delete removes the cached token, if any.
+func (c *Cache) Delete(name string) error {
+	err := os.Remove(c.filepath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -20,6 +20,7 @@ func (f defaultCallback) loop(ch chan *http.Request, stop chan bool) {
 		fmt.Println("Incoming request:")
 		fmt.Printf("  Method:  %s\n", style.GreenB.Render(r.Method))
 		fmt.Printf("  Path:    %s\n", style.GreenB.Render(r.URL.Path))
+		fmt.Printf("  Proto:   %s\n", style.GreenB.Render(r.Proto))
 
 		if len(r.Header) > 0 {
 			fmt.Println("  Headers:")
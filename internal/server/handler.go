@@ -1,7 +1,9 @@
 package server
 
 import (
-	"math/rand"
+	"crypto/rand"
+	"encoding/json"
+	mrand "math/rand"
 	"net/http"
 	"strconv"
 	"strings"
@@ -51,8 +53,37 @@ func (h *requestHandler) handleTimeout(w http.ResponseWriter, r *http.Request) {
 	time.Sleep(time.Minute * 5)
 }
 
+// handleToken is an OAuth2 token endpoint that issues a token
+// for any client using the client_credentials or refresh_token grant.
+func (h *requestHandler) handleToken(w http.ResponseWriter, r *http.Request) {
+	h.ch <- r
+
+	writeJSON := func(status int, body any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeJSON(http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case "client_credentials", "refresh_token":
+		writeJSON(http.StatusOK, map[string]any{
+			"access_token":  rand.Text(),
+			"token_type":    "Bearer",
+			"expires_in":    3600,
+			"refresh_token": rand.Text(),
+		})
+	default:
+		writeJSON(http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+	}
+}
+
 var (
-	rnd      = rand.New(rand.NewSource(time.Now().Unix()))
+	rnd      = mrand.New(mrand.NewSource(time.Now().Unix()))
 	statuses = []int{
 		// 2XX
 		http.StatusOK,
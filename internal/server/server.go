@@ -1,8 +1,12 @@
 package server
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
 	"net/http"
+	"os"
 
 	"github.com/lunjon/http/internal/style"
 )
@@ -11,6 +15,9 @@ type Options struct {
 	Port       uint
 	ShowStatus bool
 	StaticRoot string
+	// UnixSocket is the path of a Unix domain socket to listen
+	// on instead of the port, if set.
+	UnixSocket string
 }
 
 type Server struct {
@@ -35,12 +42,19 @@ func New(opts Options) *Server {
 	} else {
 		mux.HandleFunc("/~/status/{code}", handler.handleWithCode)
 		mux.HandleFunc("/~/timeout", handler.handleTimeout)
+		mux.HandleFunc("POST /~/oauth2/token", handler.handleToken)
 		mux.HandleFunc("/", handler.handleDefault)
 	}
 
+	// Accept HTTP/2 without TLS (h2c) with prior knowledge
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+
 	s := &http.Server{
-		Addr:    fmt.Sprintf(":%d", opts.Port),
-		Handler: mux,
+		Addr:      fmt.Sprintf(":%d", opts.Port),
+		Handler:   mux,
+		Protocols: protocols,
 	}
 
 	var cb callback
@@ -62,9 +76,37 @@ func New(opts Options) *Server {
 func (s *Server) Serve() error {
 	go s.cb.loop(s.ch, s.done)
 
-	fmt.Printf("Starting server on :%s.\n", style.Bold.Render(fmt.Sprint(s.options.Port)))
+	if s.options.UnixSocket == "" {
+		fmt.Printf("Starting server on :%s.\n", style.Bold.Render(fmt.Sprint(s.options.Port)))
+		fmt.Printf("Press %s to exit.\n", style.Bold.Render("CTRL-C"))
+		return s.server.ListenAndServe()
+	}
+
+	listener, err := listenUnix(s.options.UnixSocket)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Starting server on unix:%s.\n", style.Bold.Render(s.options.UnixSocket))
 	fmt.Printf("Press %s to exit.\n", style.Bold.Render("CTRL-C"))
-	return s.server.ListenAndServe()
+	return s.server.Serve(listener)
+}
+
+// Listens on the Unix domain socket at path, removing
+// a socket left from a previous server first.
+func listenUnix(path string) (net.Listener, error) {
+	info, err := os.Stat(path)
+	if err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("file exists and is not a socket: %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return net.Listen("unix", path)
 }
 
 func (s *Server) Close() error {
@@ -79,5 +121,6 @@ func ListRoutes() {
 	fmt.Println("/~/status/{code}  Respond with the given code as status.")
 	fmt.Println("                  Send 'random' as the path parameter to get a random status.")
 	fmt.Println("/~/timeout        Endpoint that hangs the request (for 5 min).")
+	fmt.Println("/~/oauth2/token   OAuth2 token endpoint for the client_credentials and refresh_token grants.")
 	fmt.Println("/*                Echo the request with 200 OK status code.")
 }
@@ -0,0 +1,131 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lunjon/http/internal/util"
+)
+
+const fileExt = ".json"
+
+var namePattern = regexp.MustCompile(`^[\w-]+$`)
+
+// Handler manages sessions stored as files in a directory.
+type Handler struct {
+	dir string
+}
+
+func NewHandler(dir string) *Handler {
+	return &Handler{dir: dir}
+}
+
+func (h *Handler) filepath(name string) (string, error) {
+	if !namePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid session name: %s", name)
+	}
+	return path.Join(h.dir, name+fileExt), nil
+}
+
+// Load returns the session with the given name,
+// or a new session if it does not exist.
+func (h *Handler) Load(name string) (*Session, error) {
+	filepath, err := h.filepath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(filepath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return New(name), nil
+		}
+		return nil, err
+	}
+
+	s := New(name)
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("invalid session file %s: %w", filepath, err)
+	}
+	s.Name = name
+	if s.Header == nil {
+		s.Header = http.Header{}
+	}
+	return s, nil
+}
+
+// Get returns the session with the given name, which must exist.
+func (h *Handler) Get(name string) (*Session, error) {
+	filepath, err := h.filepath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, _, err := util.FileExists(filepath)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("unknown session: %s", name)
+	}
+	return h.Load(name)
+}
+
+func (h *Handler) Save(s *Session) error {
+	filepath, err := h.filepath(s.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(h.dir, 0700); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath, b, 0600)
+}
+
+// List returns the names of all sessions, sorted.
+func (h *Handler) List() ([]string, error) {
+	entries, err := os.ReadDir(h.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && path.Ext(name) == fileExt {
+			names = append(names, strings.TrimSuffix(name, fileExt))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Clear removes the session with the given name.
+func (h *Handler) Clear(name string) error {
+	filepath, err := h.filepath(name)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filepath)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("unknown session: %s", name)
+	}
+	return err
+}
@@ -0,0 +1,177 @@
+package session
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultHeaders are the headers stored in a session, in addition
+// to those given to UpdateHeader. Other headers often relate to a
+// specific request, and may not be meant to be persisted.
+var DefaultHeaders = []string{"Authorization", "Proxy-Authorization", "X-API-Key"}
+
+// Session holds the state that is persisted between invocations:
+// cookies set by servers and headers given by the user.
+type Session struct {
+	Name    string      `json:"name"`
+	Header  http.Header `json:"headers"`
+	Cookies []Cookie    `json:"cookies"`
+
+	mu sync.Mutex
+}
+
+// Cookie is a cookie along with the URL it was set for.
+type Cookie struct {
+	URL      string    `json:"url"`
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Expires  time.Time `json:"expires,omitzero"`
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"httpOnly,omitempty"`
+}
+
+func (c Cookie) expired(now time.Time) bool {
+	return !c.Expires.IsZero() && c.Expires.Before(now)
+}
+
+// same returns true if the cookies have the same identity. Host-only
+// cookies, without a domain, are also identified by their host.
+func (c Cookie) same(other Cookie) bool {
+	if c.Name != other.Name || c.Domain != other.Domain || c.Path != other.Path {
+		return false
+	}
+	return c.Domain != "" || c.host() == other.host()
+}
+
+func (c Cookie) host() string {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+func (c Cookie) httpCookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   c.Domain,
+		Path:     c.Path,
+		Expires:  c.Expires,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+	}
+}
+
+func New(name string) *Session {
+	return &Session{
+		Name:    name,
+		Header:  http.Header{},
+		Cookies: []Cookie{},
+	}
+}
+
+// UpdateHeader stores the headers of DefaultHeaders and
+// the given names in the session, if they are in header.
+func (s *Session) UpdateHeader(header http.Header, names ...string) {
+	for _, name := range append(slices.Clone(DefaultHeaders), names...) {
+		name = http.CanonicalHeaderKey(name)
+		if values, found := header[name]; found {
+			s.Header[name] = values
+		}
+	}
+}
+
+// MergeHeader returns the session headers overridden by the given headers.
+func (s *Session) MergeHeader(header http.Header) http.Header {
+	merged := s.Header.Clone()
+	if merged == nil {
+		merged = http.Header{}
+	}
+	for name, values := range header {
+		merged[name] = values
+	}
+	return merged
+}
+
+// Jar returns a cookie jar that contains the cookies of the session
+// and stores any cookie set by a server in the session.
+func (s *Session) Jar() (http.CookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cookies := []Cookie{}
+	for _, c := range s.Cookies {
+		if c.expired(now) {
+			continue
+		}
+
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			continue
+		}
+		jar.SetCookies(u, []*http.Cookie{c.httpCookie()})
+		cookies = append(cookies, c)
+	}
+	s.Cookies = cookies
+
+	return &sessionJar{jar: jar, session: s}, nil
+}
+
+func (s *Session) setCookies(u *url.URL, cookies []*http.Cookie) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, c := range cookies {
+		cookie := Cookie{
+			URL:      (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String(),
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Expires:  c.Expires,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		}
+		if c.MaxAge > 0 {
+			cookie.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		}
+
+		// Replace any existing cookie, and only
+		// keep it if it has not been deleted
+		kept := []Cookie{}
+		for _, existing := range s.Cookies {
+			if !existing.same(cookie) {
+				kept = append(kept, existing)
+			}
+		}
+		if c.MaxAge >= 0 && !cookie.expired(now) {
+			kept = append(kept, cookie)
+		}
+		s.Cookies = kept
+	}
+}
+
+type sessionJar struct {
+	jar     *cookiejar.Jar
+	session *Session
+}
+
+func (j *sessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+	j.session.setCookies(u, cookies)
+}
+
+func (j *sessionJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
@@ -0,0 +1,126 @@
+package session
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setupHandler(t *testing.T) *Handler {
+	return NewHandler(t.TempDir())
+}
+
+func TestLoadNew(t *testing.T) {
+	h := setupHandler(t)
+
+	s, err := h.Load("test")
+	require.NoError(t, err)
+	require.Equal(t, "test", s.Name)
+	require.Empty(t, s.Cookies)
+	require.Empty(t, s.Header)
+}
+
+func TestLoadInvalidName(t *testing.T) {
+	h := setupHandler(t)
+
+	_, err := h.Load("../test")
+	require.Error(t, err)
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	h := setupHandler(t)
+	s := New("test")
+	s.UpdateHeader(http.Header{
+		"Authorization": {"Bearer token"},
+		"X-Tenant":      {"acme"},
+		"Content-Type":  {"application/json"},
+		"X-Request-Id":  {"1"},
+	}, "x-tenant")
+
+	jar, err := s.Jar()
+	require.NoError(t, err)
+	u, _ := url.Parse("http://localhost/login")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "session", Value: "abc"},
+		{Name: "deleted", Value: "x", MaxAge: -1},
+	})
+
+	err = h.Save(s)
+	require.NoError(t, err)
+
+	loaded, err := h.Get("test")
+	require.NoError(t, err)
+	require.Equal(t, "Bearer token", loaded.Header.Get("Authorization"))
+	require.Equal(t, "acme", loaded.Header.Get("X-Tenant"))
+	require.Empty(t, loaded.Header.Get("Content-Type"))
+	require.Empty(t, loaded.Header.Get("X-Request-Id"))
+	require.Len(t, loaded.Cookies, 1)
+
+	jar, err = loaded.Jar()
+	require.NoError(t, err)
+	cookies := jar.Cookies(u)
+	require.Len(t, cookies, 1)
+	require.Equal(t, "abc", cookies[0].Value)
+}
+
+func TestSetCookiesReplaces(t *testing.T) {
+	s := New("test")
+	jar, _ := s.Jar()
+	u, _ := url.Parse("http://localhost")
+
+	jar.SetCookies(u, []*http.Cookie{{Name: "a", Value: "1"}})
+	jar.SetCookies(u, []*http.Cookie{{Name: "a", Value: "2"}})
+	require.Len(t, s.Cookies, 1)
+	require.Equal(t, "2", s.Cookies[0].Value)
+
+	jar.SetCookies(u, []*http.Cookie{{Name: "a", Expires: time.Now().Add(-time.Hour)}})
+	require.Empty(t, s.Cookies)
+}
+
+func TestSetCookiesHostOnly(t *testing.T) {
+	s := New("test")
+	jar, _ := s.Jar()
+	a, _ := url.Parse("http://a.example")
+	b, _ := url.Parse("http://b.example")
+
+	// Host-only cookies of different hosts are different cookies
+	jar.SetCookies(a, []*http.Cookie{{Name: "id", Value: "a"}})
+	jar.SetCookies(b, []*http.Cookie{{Name: "id", Value: "b"}})
+	require.Len(t, s.Cookies, 2)
+
+	jar.SetCookies(a, []*http.Cookie{{Name: "id", Value: "a2"}})
+	require.Len(t, s.Cookies, 2)
+	require.Equal(t, "a2", jar.Cookies(a)[0].Value)
+	require.Equal(t, "b", jar.Cookies(b)[0].Value)
+}
+
+func TestMergeHeader(t *testing.T) {
+	s := New("test")
+	s.UpdateHeader(http.Header{"A": {"session"}, "B": {"session"}}, "A", "B")
+
+	merged := s.MergeHeader(http.Header{"B": {"request"}})
+	require.Equal(t, "session", merged.Get("A"))
+	require.Equal(t, "request", merged.Get("B"))
+}
+
+func TestListAndClear(t *testing.T) {
+	h := setupHandler(t)
+	names, err := h.List()
+	require.NoError(t, err)
+	require.Empty(t, names)
+
+	require.NoError(t, h.Save(New("b")))
+	require.NoError(t, h.Save(New("a")))
+
+	names, err = h.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, names)
+
+	require.NoError(t, h.Clear("a"))
+	require.Error(t, h.Clear("a"))
+	_, err = h.Get("a")
+	require.Error(t, err)
+}